@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/djeeteg007/tf-why/internal/analysis"
+	"github.com/djeeteg007/tf-why/internal/compare"
+	"github.com/djeeteg007/tf-why/internal/config"
+	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/render"
+)
+
+// runDiff implements `tf-why diff [flags] old.json new.json` and returns the exit code.
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format: text, json or markdown")
+	only := fs.String("only", "", "Comma-separated resource types to include")
+	excludeTag := fs.String("exclude-tag", "", "Comma-separated tags to exclude")
+	noColor := fs.Bool("no-color", false, "Disable colored output")
+	profile := fs.String("profile", "", "Configuration profile to apply (default: matched by TF_WORKSPACE or --dir)")
+	tfDir := fs.String("dir", "", "Directory matched against profile dirs patterns (default: current dir)")
+	configFile := fs.String("config", "", "Path to configuration file (default: "+config.DefaultFile+" if present)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  tf-why diff [flags] old.json new.json\n\n")
+		fmt.Fprintf(os.Stderr, "Compares two plan JSON files and reports resources whose actions or\n")
+		fmt.Fprintf(os.Stderr, "planned values changed, and findings that appeared, disappeared or\n")
+		fmt.Fprintf(os.Stderr, "changed severity.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+
+	// Accept flags before, between or after the plan files.
+	var files []string
+	for rest := args; ; {
+		_ = fs.Parse(rest)
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if len(files) != 2 {
+		fs.Usage()
+		return 1
+	}
+
+	configureColor(*noColor)
+
+	cfg, sel, err := loadConfig(*configFile, *profile, *tfDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	settings := cfg.Effective(sel.Name)
+
+	oldPlan, err := parsePlanFile(files[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	newPlan, err := parsePlanFile(files[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	opts := analysis.Options{
+		ExcludeTags:   settings.ExcludeTags,
+		Overrides:     settings.Overrides,
+		EnabledRules:  settings.EnabledRules,
+		DisabledRules: settings.DisabledRules,
+		Scoring:       cfg.Scoring,
+		RuleConfig:    &cfg.Rules,
+	}
+	if *only != "" {
+		opts.OnlyTypes = splitCSV(*only)
+	}
+	if *excludeTag != "" {
+		opts.ExcludeTags = append(opts.ExcludeTags, splitCSV(*excludeTag)...)
+	}
+
+	report := compare.Plans(oldPlan, newPlan, opts)
+
+	switch *format {
+	case "json":
+		if err := render.CompareJSON(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	case "markdown":
+		render.CompareMarkdown(os.Stdout, report)
+	case "text":
+		render.CompareText(os.Stdout, report)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use 'text', 'json' or 'markdown')\n", *format)
+		return 1
+	}
+	return 0
+}
+
+func parsePlanFile(path string) (*plan.Plan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open plan file: %w", err)
+	}
+	defer f.Close()
+	return plan.Parse(f)
+}
@@ -4,12 +4,14 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/djeeteg007/tf-why/internal/analysis"
+	"github.com/djeeteg007/tf-why/internal/config"
 	"github.com/djeeteg007/tf-why/internal/plan"
 	"github.com/djeeteg007/tf-why/internal/render"
 )
@@ -17,16 +19,27 @@ import (
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:]))
+	}
+
 	planFile := flag.String("plan", "", "Path to Terraform plan JSON file (default: read from stdin)")
 	run := flag.Bool("run", false, "Run terraform plan + show automatically and analyze the result")
 	tfDir := flag.String("dir", "", "Terraform working directory (used with --run)")
 	format := flag.String("format", "text", "Output format: text or json")
 	ci := flag.Bool("ci", false, "CI mode: set exit codes based on severity threshold")
 	failOn := flag.String("fail-on", "high", "Severity threshold for non-zero exit in CI mode: low, medium, or high")
+	failScore := flag.Int("fail-score", 0, "Risk score threshold for non-zero exit in CI mode (0 = disabled)")
 	only := flag.String("only", "", "Comma-separated resource types to include (e.g., aws_db_instance,aws_ecs_service)")
 	excludeTag := flag.String("exclude-tag", "", "Comma-separated tags to exclude (e.g., security,cost)")
 	maxFindings := flag.Int("max-findings", 20, "Maximum number of findings to report")
 	noColor := flag.Bool("no-color", false, "Disable colored output")
+	baselineFile := flag.String("baseline", "", "Baseline file; matching findings are reported as existing and ignored by --ci")
+	writeBaseline := flag.String("write-baseline", "", "Write fingerprints of all current findings to this baseline file")
+	profile := flag.String("profile", "", "Configuration profile to apply (default: matched by TF_WORKSPACE or --dir)")
+	configFile := flag.String("config", "", "Path to configuration file (default: "+config.DefaultFile+" if present)")
+	var overrides overrideFlags
+	flag.Var(&overrides, "override", "Severity/tag override, repeatable (e.g., rule=ecs,module=module.prod,severity=high)")
 	showVersion := flag.Bool("version", false, "Print version and exit")
 
 	flag.Usage = func() {
@@ -34,13 +47,15 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Usage:\n")
 		fmt.Fprintf(os.Stderr, "  tf-why --run [flags]                          # run terraform plan automatically\n")
 		fmt.Fprintf(os.Stderr, "  terraform show -json tfplan | tf-why [flags]  # pipe plan JSON via stdin\n")
-		fmt.Fprintf(os.Stderr, "  tf-why --plan plan.json [flags]               # read plan JSON from file\n\n")
+		fmt.Fprintf(os.Stderr, "  tf-why --plan plan.json [flags]               # read plan JSON from file\n")
+		fmt.Fprintf(os.Stderr, "  tf-why diff [flags] old.json new.json         # compare two plans\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nCI Exit Codes:\n")
 		fmt.Fprintf(os.Stderr, "  0   — severity below threshold (or no findings)\n")
 		fmt.Fprintf(os.Stderr, "  10  — medium severity threshold reached\n")
 		fmt.Fprintf(os.Stderr, "  20  — high severity threshold reached\n")
+		fmt.Fprintf(os.Stderr, "  30  — risk score threshold (--fail-score) reached\n")
 		fmt.Fprintf(os.Stderr, "  1   — error (invalid input, flags, etc.)\n")
 	}
 
@@ -51,13 +66,26 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Disable color if requested, if NO_COLOR env is set, or if stdout is not a terminal.
-	if *noColor || os.Getenv("NO_COLOR") != "" {
-		render.ColorEnabled = false
-	} else if fi, err := os.Stdout.Stat(); err == nil {
-		if fi.Mode()&os.ModeCharDevice == 0 {
-			render.ColorEnabled = false
-		}
+	configureColor(*noColor)
+
+	// Load configuration and merge the selected profile into it.
+	cfg, sel, err := loadConfig(*configFile, *profile, *tfDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	settings := cfg.Effective(sel.Name)
+
+	// Explicit --fail-on/--fail-score win over the configured thresholds.
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+	if !setFlags["fail-on"] && settings.FailOn != "" {
+		*failOn = settings.FailOn
+	}
+	if !setFlags["fail-score"] && settings.FailScore != 0 {
+		*failScore = settings.FailScore
 	}
 
 	// Open input.
@@ -88,15 +116,39 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Build options.
+	// Build options. Flag overrides are applied after config overrides so
+	// they take precedence.
 	opts := analysis.Options{
-		MaxFindings: *maxFindings,
+		MaxFindings:   *maxFindings,
+		ExcludeTags:   settings.ExcludeTags,
+		Overrides:     append(settings.Overrides, overrides...),
+		EnabledRules:  settings.EnabledRules,
+		DisabledRules: settings.DisabledRules,
+		Profile:       sel.Name,
+		ProfileSource: sel.Source,
+		Scoring:       cfg.Scoring,
+		RuleConfig:    &cfg.Rules,
 	}
 	if *only != "" {
 		opts.OnlyTypes = splitCSV(*only)
 	}
 	if *excludeTag != "" {
-		opts.ExcludeTags = splitCSV(*excludeTag)
+		opts.ExcludeTags = append(opts.ExcludeTags, splitCSV(*excludeTag)...)
+	}
+
+	if *writeBaseline != "" {
+		if err := writeBaselineFile(*writeBaseline, p, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *baselineFile != "" {
+		b, err := analysis.LoadBaseline(*baselineFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Baseline = b
 	}
 
 	// Analyze.
@@ -129,6 +181,9 @@ func main() {
 				os.Exit(10)
 			}
 		}
+		if *failScore > 0 && result.Score >= *failScore {
+			os.Exit(30)
+		}
 		os.Exit(0)
 	}
 }
@@ -182,6 +237,107 @@ func runTerraformPlan(dir string) (io.Reader, error) {
 	return strings.NewReader(string(jsonOut)), nil
 }
 
+// configureColor disables color if requested, if NO_COLOR env is set, or if
+// stdout is not a terminal.
+func configureColor(noColor bool) {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		render.ColorEnabled = false
+	} else if fi, err := os.Stdout.Stat(); err == nil {
+		if fi.Mode()&os.ModeCharDevice == 0 {
+			render.ColorEnabled = false
+		}
+	}
+}
+
+// loadConfig loads the configuration file (explicit or default) and selects
+// a profile by name, TF_WORKSPACE or directory.
+func loadConfig(configFile, profile, dir string) (*config.Config, config.Selection, error) {
+	var cfg *config.Config
+	var err error
+	if configFile != "" {
+		cfg, err = config.Load(configFile)
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		return nil, config.Selection{}, err
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	sel, err := cfg.SelectProfile(profile, os.Getenv("TF_WORKSPACE"), dir)
+	if err != nil {
+		return nil, config.Selection{}, err
+	}
+	return cfg, sel, nil
+}
+
+// writeBaselineFile records every current finding, ignoring --max-findings
+// so that truncated findings do not show up as new on the next run.
+func writeBaselineFile(path string, p *plan.Plan, opts analysis.Options) error {
+	opts.MaxFindings = math.MaxInt
+	opts.Baseline = nil
+	result := analysis.Analyze(p, opts)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating baseline file: %w", err)
+	}
+	b := analysis.NewBaseline(result.Findings)
+	if err := analysis.WriteBaseline(f, b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing baseline file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote baseline with %d finding(s) to %s\n", len(b.Findings), path)
+	return nil
+}
+
+// overrideFlags collects repeated --override values.
+type overrideFlags []analysis.Override
+
+func (o *overrideFlags) String() string {
+	return fmt.Sprintf("%d override(s)", len(*o))
+}
+
+// Set parses a comma-separated list of key=value pairs. Keys are rule, type,
+// address, module, severity, add-tag and remove-tag; the tag keys may repeat.
+func (o *overrideFlags) Set(value string) error {
+	var ov analysis.Override
+	for _, pair := range splitCSV(value) {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		switch strings.TrimSpace(key) {
+		case "rule":
+			ov.Rule = val
+		case "type":
+			ov.ResourceType = val
+		case "address":
+			ov.Address = val
+		case "module":
+			ov.Module = val
+		case "severity":
+			ov.Severity = val
+		case "add-tag":
+			ov.AddTags = append(ov.AddTags, val)
+		case "remove-tag":
+			ov.RemoveTags = append(ov.RemoveTags, val)
+		default:
+			return fmt.Errorf("unknown override key %q", key)
+		}
+	}
+	if err := ov.Validate(); err != nil {
+		return err
+	}
+	*o = append(*o, ov)
+	return nil
+}
+
 func splitCSV(s string) []string {
 	parts := strings.Split(s, ",")
 	var result []string
@@ -186,7 +186,7 @@ func TestCLIExcludeTag(t *testing.T) {
 
 func TestCLIMaxFindings(t *testing.T) {
 	bin := buildBinary(t)
-	fixture := filepath.Join(fixtureDir(), "sg_inline_multi.json")
+	fixture := filepath.Join(fixtureDir(), "sg_catalog.json")
 	out, code := runBinary(t, bin, []string{"--format", "json", "--max-findings", "1"}, fixture)
 	if code != 0 {
 		t.Errorf("expected exit 0, got %d", code)
@@ -200,3 +200,207 @@ func TestCLIMaxFindings(t *testing.T) {
 		t.Errorf("expected 1 finding with --max-findings 1, got %d", count)
 	}
 }
+
+func TestCLIOverrideFlag(t *testing.T) {
+	bin := buildBinary(t)
+	fixture := filepath.Join(fixtureDir(), "ecs_modules.json")
+	out, code := runBinary(t, bin, []string{
+		"--ci", "--fail-on", "high", "--format", "json",
+		"--override", "rule=ecs,module=module.prod,severity=high",
+	}, fixture)
+	if code != 20 {
+		t.Errorf("expected exit 20 after overriding to HIGH, got %d", code)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	first := result["findings"].([]interface{})[0].(map[string]interface{})
+	if first["severity"] != "high" || first["original_severity"] != "medium" {
+		t.Errorf("expected high (original medium), got %v (original %v)", first["severity"], first["original_severity"])
+	}
+}
+
+func TestCLIConfigFile(t *testing.T) {
+	bin := buildBinary(t)
+	cfgPath := filepath.Join(t.TempDir(), "tf-why.json")
+	cfg := `{"overrides":[{"rule":"ecs","severity":"low"}]}`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fixture := filepath.Join(fixtureDir(), "ecs_scale_down.json")
+	_, code := runBinary(t, bin, []string{"--ci", "--fail-on", "medium", "--config", cfgPath}, fixture)
+	if code != 0 {
+		t.Errorf("expected exit 0 after lowering ECS findings to LOW, got %d", code)
+	}
+}
+
+func TestCLIInvalidOverride(t *testing.T) {
+	bin := buildBinary(t)
+	fixture := filepath.Join(fixtureDir(), "ecs_scale_down.json")
+	_, code := runBinary(t, bin, []string{"--override", "severity=high"}, fixture)
+	if code == 0 {
+		t.Error("expected non-zero exit for override without selector")
+	}
+}
+
+func writeProfilesConfig(t *testing.T) string {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "tf-why.json")
+	cfg := `{
+		"profiles": {
+			"dev":  {"overrides": [{"rule": "ecs", "severity": "low"}]},
+			"prod": {"fail_on": "medium", "workspaces": ["prod*"]}
+		}
+	}`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return cfgPath
+}
+
+func TestCLIProfileFlag(t *testing.T) {
+	bin := buildBinary(t)
+	cfgPath := writeProfilesConfig(t)
+	fixture := filepath.Join(fixtureDir(), "ecs_scale_down.json")
+	out, code := runBinary(t, bin, []string{"--config", cfgPath, "--profile", "dev", "--ci", "--fail-on", "medium"}, fixture)
+	if code != 0 {
+		t.Errorf("expected exit 0 with dev profile lowering ECS findings, got %d", code)
+	}
+	if !strings.Contains(out, "PROFILE") || !strings.Contains(out, "dev") || !strings.Contains(out, "--profile") {
+		t.Errorf("expected selected profile in header, got:\n%s", out)
+	}
+}
+
+func TestCLIProfileFromWorkspace(t *testing.T) {
+	bin := buildBinary(t)
+	cfgPath := writeProfilesConfig(t)
+	t.Setenv("TF_WORKSPACE", "production")
+	fixture := filepath.Join(fixtureDir(), "ecs_scale_down.json")
+	out, code := runBinary(t, bin, []string{"--config", cfgPath, "--ci", "--format", "json"}, fixture)
+	if code != 10 {
+		t.Errorf("expected exit 10 with prod profile fail_on medium, got %d", code)
+	}
+	if !strings.Contains(out, `"profile": "prod"`) {
+		t.Errorf("expected profile in JSON output, got:\n%s", out)
+	}
+}
+
+func TestCLIBaseline(t *testing.T) {
+	bin := buildBinary(t)
+	fixture := filepath.Join(fixtureDir(), "iam_wildcard.json")
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	if _, code := runBinary(t, bin, []string{"--plan", fixture, "--write-baseline", baseline}, ""); code != 0 {
+		t.Fatalf("expected exit 0 writing baseline, got %d", code)
+	}
+	first, err := os.ReadFile(baseline)
+	if err != nil {
+		t.Fatalf("baseline not written: %v", err)
+	}
+
+	// Regenerating must be byte-for-byte identical.
+	runBinary(t, bin, []string{"--plan", fixture, "--write-baseline", baseline}, "")
+	second, _ := os.ReadFile(baseline)
+	if !bytes.Equal(first, second) {
+		t.Errorf("baseline is not stable:\n%s\nvs\n%s", first, second)
+	}
+
+	out, code := runBinary(t, bin, []string{"--plan", fixture, "--baseline", baseline, "--ci", "--fail-on", "low"}, "")
+	if code != 0 {
+		t.Errorf("expected exit 0 when all findings are in the baseline, got %d", code)
+	}
+	if !strings.Contains(out, "EXISTING FINDINGS") {
+		t.Errorf("expected existing findings section, got:\n%s", out)
+	}
+
+	// A different plan still fails on its own findings.
+	other := filepath.Join(fixtureDir(), "kms_delete.json")
+	if _, code := runBinary(t, bin, []string{"--plan", other, "--baseline", baseline, "--ci"}, ""); code != 20 {
+		t.Errorf("expected exit 20 for findings not in the baseline, got %d", code)
+	}
+}
+
+func TestCLIDiff(t *testing.T) {
+	bin := buildBinary(t)
+	oldPlan := filepath.Join(fixtureDir(), "rds_minor_upgrade.json")
+	newPlan := filepath.Join(fixtureDir(), "rds_replace.json")
+
+	out, code := runBinary(t, bin, []string{"diff", "--format", "json", oldPlan, newPlan}, "")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d\n%s", code, out)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if result["new_overall_severity"] != "high" {
+		t.Errorf("expected new severity high, got %v", result["new_overall_severity"])
+	}
+	if len(result["added_findings"].([]interface{})) == 0 {
+		t.Error("expected added findings")
+	}
+
+	// Flags are also accepted after the plan files.
+	out, code = runBinary(t, bin, []string{"diff", oldPlan, newPlan, "--format", "markdown"}, "")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(out, "## Terraform plan comparison") || !strings.Contains(out, "update → replace") {
+		t.Errorf("unexpected markdown output:\n%s", out)
+	}
+}
+
+func TestCLIDiffProfileFromDir(t *testing.T) {
+	bin := buildBinary(t)
+	cfgPath := filepath.Join(t.TempDir(), "tf-why.json")
+	cfg := `{"profiles": {"sandbox": {"dirs": ["*/sandbox"], "overrides": [{"rule": "rds", "severity": "low"}]}}}`
+	if err := os.WriteFile(cfgPath, []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oldPlan := filepath.Join(fixtureDir(), "rds_minor_upgrade.json")
+	newPlan := filepath.Join(fixtureDir(), "rds_replace.json")
+
+	out, code := runBinary(t, bin, []string{"diff", "--config", cfgPath, "--dir", "infra/sandbox", "--format", "json", oldPlan, newPlan}, "")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d\n%s", code, out)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if result["new_overall_severity"] != "low" {
+		t.Errorf("expected the sandbox profile to lower RDS findings, got %v", result["new_overall_severity"])
+	}
+}
+
+func TestCLIDiffRequiresTwoPlans(t *testing.T) {
+	bin := buildBinary(t)
+	_, code := runBinary(t, bin, []string{"diff", filepath.Join(fixtureDir(), "rds_replace.json")}, "")
+	if code != 1 {
+		t.Errorf("expected exit 1 with a single plan, got %d", code)
+	}
+}
+
+func TestCLIFailScore(t *testing.T) {
+	bin := buildBinary(t)
+	fixture := filepath.Join(fixtureDir(), "instances_replace.json")
+	// The severity exit code wins when both thresholds are reached.
+	out, code := runBinary(t, bin, []string{"--ci", "--fail-on", "high", "--fail-score", "1000", "--format", "json"}, fixture)
+	if code != 20 {
+		t.Errorf("expected severity exit 20 to take precedence, got %d", code)
+	}
+	if !strings.Contains(out, `"risk_score": 300`) {
+		t.Errorf("expected risk_score in JSON output, got:\n%s", out)
+	}
+
+	fixture = filepath.Join(fixtureDir(), "ecs_modules.json")
+	_, code = runBinary(t, bin, []string{"--ci", "--fail-on", "high", "--fail-score", "50"}, fixture)
+	if code != 30 {
+		t.Errorf("expected exit 30 when only the score threshold is reached, got %d", code)
+	}
+	_, code = runBinary(t, bin, []string{"--ci", "--fail-on", "high", "--fail-score", "500"}, fixture)
+	if code != 0 {
+		t.Errorf("expected exit 0 below both thresholds, got %d", code)
+	}
+}
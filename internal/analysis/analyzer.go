@@ -6,6 +6,7 @@ import (
 
 	"github.com/djeeteg007/tf-why/internal/plan"
 	"github.com/djeeteg007/tf-why/internal/rules"
+	"github.com/djeeteg007/tf-why/internal/util"
 )
 
 // Severity levels in increasing order.
@@ -44,13 +45,29 @@ func ParseSeverity(s string) Severity {
 }
 
 // Finding is a single analysis finding.
+// OriginalSeverity is the severity assigned by the rule before any
+// configured overrides were applied.
 type Finding struct {
-	Severity        Severity `json:"severity"`
-	Tags            []string `json:"tags"`
-	Title           string   `json:"title"`
-	Address         string   `json:"address"`
-	Why             []string `json:"why"`
-	Recommendations []string `json:"recommendations"`
+	Severity         Severity `json:"severity"`
+	OriginalSeverity Severity `json:"original_severity"`
+	RuleID           string   `json:"rule"`
+	Tags             []string `json:"tags"`
+	Title            string   `json:"title"`
+	Address          string   `json:"address"`
+	ResourceType     string   `json:"resource_type"`
+	Module           string   `json:"module,omitempty"`
+	Action           string   `json:"action"`
+	Fingerprint      string   `json:"fingerprint"`
+	Why              []string `json:"why"`
+	Recommendations  []string `json:"recommendations"`
+
+	Score          int           `json:"score"`
+	ScoreBreakdown []ScoreFactor `json:"score_breakdown"`
+}
+
+// Overridden reports whether an override changed the finding's severity.
+func (f Finding) Overridden() bool {
+	return f.Severity != f.OriginalSeverity
 }
 
 // Summary holds aggregate counts.
@@ -62,10 +79,18 @@ type Summary struct {
 }
 
 // Result is the complete analysis output.
+// Profile and ProfileSource are set when a configuration profile was applied.
+// When a baseline is used, Findings holds only new findings and
+// ExistingFindings those matched by the baseline; OverallSeverity is computed
+// from new findings only.
 type Result struct {
-	Summary         Summary  `json:"summary"`
-	Findings        []Finding `json:"findings"`
-	OverallSeverity Severity `json:"overall_severity"`
+	Summary          Summary   `json:"summary"`
+	Findings         []Finding `json:"findings"`
+	ExistingFindings []Finding `json:"existing_findings,omitempty"`
+	OverallSeverity  Severity  `json:"overall_severity"`
+	Score            int       `json:"score"`
+	Profile          string    `json:"profile,omitempty"`
+	ProfileSource    string    `json:"profile_source,omitempty"`
 }
 
 // Options controls filtering and limits.
@@ -73,40 +98,85 @@ type Options struct {
 	OnlyTypes   []string // filter to these resource types
 	ExcludeTags []string // exclude findings with any of these tags
 	MaxFindings int      // max number of findings to return
+	Overrides   []Override
+
+	EnabledRules  []string // rule ID patterns to run (empty = all)
+	DisabledRules []string // rule ID patterns to skip
+
+	Profile       string // selected profile, reported in the result
+	ProfileSource string // how the profile was selected
+
+	Baseline *Baseline // accepted findings to report separately
+
+	Scoring *ScoreWeights // weights merged over DefaultScoreWeights
+
+	RuleConfig *rules.Config // rule parameters (nil = rules.DefaultConfig)
 }
 
 // Analyze runs all rules against the plan and returns the result.
 func Analyze(p *plan.Plan, opts Options) Result {
 	summary := computeSummary(p)
 
-	allRules := rules.AllRules()
+	ruleConfig := rules.DefaultConfig()
+	if opts.RuleConfig != nil {
+		ruleConfig = *opts.RuleConfig
+	}
+	ruleConfig.OwnerAccounts = rules.PlanAccounts(p)
+	resourceRules := selectRules(rules.Rules(ruleConfig), opts.EnabledRules, opts.DisabledRules)
+	planRules := selectRules(rules.PlanRules(ruleConfig), opts.EnabledRules, opts.DisabledRules)
 
+	// Findings from both kinds of rules go through the same type filter and
+	// are deduplicated by fingerprint.
 	var findings []Finding
+	seen := make(map[string]bool)
+	add := func(ruleID string, rf rules.RuleFinding, rc plan.ResourceChange) {
+		if len(opts.OnlyTypes) > 0 && !containsStr(opts.OnlyTypes, rc.Type) {
+			return
+		}
+		f := newFinding(ruleID, rf, rc)
+		f.Fingerprint = Fingerprint(f)
+		if seen[f.Fingerprint] {
+			return
+		}
+		seen[f.Fingerprint] = true
+		findings = append(findings, f)
+	}
+
+	instances := make(map[string]int)
 	for _, rc := range p.ResourceChanges {
 		action := rc.Change.Actions.ActionType()
-		if action == plan.ActionNoop || action == plan.ActionRead {
+		// Data sources are read, not changed, but a deferred read can still
+		// carry configuration worth checking (e.g. aws_iam_policy_document).
+		if action == plan.ActionNoop || (action == plan.ActionRead && rc.Mode != "data") {
 			continue
 		}
+		instances[instanceKey(rc.Address)]++
 
-		if len(opts.OnlyTypes) > 0 && !containsStr(opts.OnlyTypes, rc.Type) {
-			continue
+		for _, rule := range resourceRules {
+			for _, rf := range rule.Evaluate(rc) {
+				add(rule.ID(), rf, rc)
+			}
 		}
+	}
 
-		for _, rule := range allRules {
-			ruleFindings := rule.Evaluate(rc)
-			for _, rf := range ruleFindings {
-				findings = append(findings, Finding{
-					Severity:        Severity(rf.Severity),
-					Tags:            rf.Tags,
-					Title:           rf.Title,
-					Address:         rf.Address,
-					Why:             rf.Why,
-					Recommendations: rf.Recommendations,
-				})
+	// Plan-wide rules report on resources by address.
+	if len(planRules) > 0 {
+		idx := plan.NewIndex(p)
+		for _, rule := range planRules {
+			for _, rf := range rule.EvaluatePlan(idx) {
+				if rc, ok := idx.Change(rf.Address); ok {
+					add(rule.ID(), rf, *rc)
+				}
 			}
 		}
 	}
 
+	// Apply severity/tag overrides before tag filtering and sorting so that
+	// both operate on the effective values.
+	if len(opts.Overrides) > 0 {
+		applyOverrides(findings, opts.Overrides)
+	}
+
 	// Filter by excluded tags.
 	if len(opts.ExcludeTags) > 0 {
 		findings = filterByExcludedTags(findings, opts.ExcludeTags)
@@ -120,6 +190,37 @@ func Analyze(p *plan.Plan, opts Options) Result {
 		return findings[i].Address < findings[j].Address
 	})
 
+	// Split off findings accepted in the baseline.
+	var existing []Finding
+	if opts.Baseline != nil {
+		var fresh []Finding
+		for _, f := range findings {
+			if opts.Baseline.Contains(f) {
+				existing = append(existing, f)
+			} else {
+				fresh = append(fresh, f)
+			}
+		}
+		findings = fresh
+	}
+
+	// Score after overrides so that effective severity and tags are used,
+	// and before truncation so that --max-findings cannot hide risk.
+	weights := DefaultScoreWeights()
+	if opts.Scoring != nil {
+		weights = weights.Merge(*opts.Scoring)
+	}
+	var score int
+	for i := range findings {
+		f := &findings[i]
+		f.Score, f.ScoreBreakdown = scoreFinding(*f, instances[instanceKey(f.Address)], weights)
+		score += f.Score
+	}
+	for i := range existing {
+		f := &existing[i]
+		f.Score, f.ScoreBreakdown = scoreFinding(*f, instances[instanceKey(f.Address)], weights)
+	}
+
 	// Apply max findings.
 	maxFindings := opts.MaxFindings
 	if maxFindings <= 0 {
@@ -128,6 +229,9 @@ func Analyze(p *plan.Plan, opts Options) Result {
 	if len(findings) > maxFindings {
 		findings = findings[:maxFindings]
 	}
+	if len(existing) > maxFindings {
+		existing = existing[:maxFindings]
+	}
 
 	// Compute overall severity.
 	var overall Severity
@@ -138,10 +242,58 @@ func Analyze(p *plan.Plan, opts Options) Result {
 	}
 
 	return Result{
-		Summary:         summary,
-		Findings:        findings,
-		OverallSeverity: overall,
+		Summary:          summary,
+		Findings:         findings,
+		ExistingFindings: existing,
+		OverallSeverity:  overall,
+		Score:            score,
+		Profile:          opts.Profile,
+		ProfileSource:    opts.ProfileSource,
+	}
+}
+
+// newFinding converts a rule finding about rc.
+func newFinding(ruleID string, rf rules.RuleFinding, rc plan.ResourceChange) Finding {
+	return Finding{
+		Severity:         Severity(rf.Severity),
+		OriginalSeverity: Severity(rf.Severity),
+		RuleID:           ruleID,
+		Tags:             rf.Tags,
+		Title:            rf.Title,
+		Address:          rf.Address,
+		ResourceType:     rc.Type,
+		Module:           rc.ModuleAddress,
+		Action:           rc.Change.Actions.ActionType().String(),
+		Why:              rf.Why,
+		Recommendations:  rf.Recommendations,
+	}
+}
+
+// selectRules filters rules by ID glob patterns.
+func selectRules[R interface{ ID() string }](all []R, enabled, disabled []string) []R {
+	if len(enabled) == 0 && len(disabled) == 0 {
+		return all
 	}
+	var result []R
+	for _, r := range all {
+		if len(enabled) > 0 && !matchAnyGlob(enabled, r.ID()) {
+			continue
+		}
+		if matchAnyGlob(disabled, r.ID()) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func matchAnyGlob(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if util.MatchGlob(p, s) {
+			return true
+		}
+	}
+	return false
 }
 
 func computeSummary(p *plan.Plan) Summary {
@@ -59,7 +59,7 @@ func TestAnalyzeExcludeTag(t *testing.T) {
 
 func TestAnalyzeMaxFindings(t *testing.T) {
 	// Use a fixture that generates many findings
-	p := loadFixture(t, "sg_inline_multi.json")
+	p := loadFixture(t, "sg_catalog.json")
 	result := Analyze(p, Options{MaxFindings: 2})
 	if len(result.Findings) > 2 {
 		t.Errorf("expected at most 2 findings, got %d", len(result.Findings))
@@ -111,6 +111,64 @@ func TestAnalyzeNoChanges(t *testing.T) {
 	}
 }
 
+func TestAnalyzeDataSourceRead(t *testing.T) {
+	p := loadFixture(t, "iam_resource_policies.json")
+	result := Analyze(p, Options{MaxFindings: 20})
+	for _, f := range result.Findings {
+		if f.Address == "data.aws_iam_policy_document.deploy" {
+			if f.Action != "read" {
+				t.Errorf("expected action read, got %s", f.Action)
+			}
+			return
+		}
+	}
+	t.Error("expected a finding for the deferred aws_iam_policy_document read")
+}
+
+func TestAnalyzePlanRule(t *testing.T) {
+	p := loadFixture(t, "aurora_topology.json")
+	result := Analyze(p, Options{MaxFindings: 50})
+	found := false
+	for _, f := range result.Findings {
+		if f.RuleID == "aurora-topology" && f.Address == "aws_rds_cluster_instance.audit" {
+			found = true
+			if f.Action != "delete" || f.ResourceType != "aws_rds_cluster_instance" {
+				t.Errorf("expected plan-wide finding to carry its resource's action and type, got %s %s", f.Action, f.ResourceType)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected a plan-wide aurora-topology finding")
+	}
+
+	result = Analyze(p, Options{MaxFindings: 50, OnlyTypes: []string{"aws_rds_cluster"}})
+	for _, f := range result.Findings {
+		if f.ResourceType != "aws_rds_cluster" {
+			t.Errorf("--only should apply to plan-wide findings, got %s", f.Address)
+		}
+	}
+
+	result = Analyze(p, Options{MaxFindings: 50, DisabledRules: []string{"aurora-*"}})
+	for _, f := range result.Findings {
+		if f.RuleID == "aurora-topology" {
+			t.Errorf("disabled_rules should apply to plan-wide rules, got %s", f.Title)
+		}
+	}
+}
+
+func TestAnalyzeDeduplicatesFindings(t *testing.T) {
+	p := loadFixture(t, "rds_replace.json")
+	p.ResourceChanges = append(p.ResourceChanges, p.ResourceChanges...)
+	result := Analyze(p, Options{MaxFindings: 50})
+	seen := make(map[string]bool)
+	for _, f := range result.Findings {
+		if seen[f.Fingerprint] {
+			t.Errorf("duplicate finding %q on %s", f.Title, f.Address)
+		}
+		seen[f.Fingerprint] = true
+	}
+}
+
 func TestParseSeverity(t *testing.T) {
 	tests := []struct {
 		input string
@@ -131,3 +189,148 @@ func TestParseSeverity(t *testing.T) {
 		}
 	}
 }
+
+func TestAnalyzeSeverityOverrides(t *testing.T) {
+	p := loadFixture(t, "ecs_modules.json")
+	result := Analyze(p, Options{
+		MaxFindings: 20,
+		Overrides: []Override{
+			{Rule: "ecs", Module: "module.prod", Severity: "high"},
+			{ResourceType: "aws_ecs_*", Address: "module.dev.*", Severity: "low", AddTags: []string{"dev"}, RemoveTags: []string{"ops"}},
+		},
+	})
+	if len(result.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(result.Findings))
+	}
+
+	prod, dev := result.Findings[0], result.Findings[1]
+	if prod.Address != "module.prod.aws_ecs_service.api" || prod.Severity != SeverityHigh {
+		t.Errorf("expected prod finding first with HIGH severity, got %s %s", prod.Address, prod.Severity)
+	}
+	if prod.OriginalSeverity != SeverityMedium || !prod.Overridden() {
+		t.Errorf("expected prod original severity MEDIUM, got %s", prod.OriginalSeverity)
+	}
+	if dev.Severity != SeverityLow || dev.OriginalSeverity != SeverityMedium {
+		t.Errorf("expected dev finding LOW (was MEDIUM), got %s (was %s)", dev.Severity, dev.OriginalSeverity)
+	}
+	if !containsStr(dev.Tags, "dev") || containsStr(dev.Tags, "ops") {
+		t.Errorf("expected dev tags adjusted, got %v", dev.Tags)
+	}
+	if result.OverallSeverity != SeverityHigh {
+		t.Errorf("expected overall severity to use overridden value, got %s", result.OverallSeverity)
+	}
+}
+
+func TestAnalyzeOverrideBeforeTagFilter(t *testing.T) {
+	p := loadFixture(t, "ecs_modules.json")
+	result := Analyze(p, Options{
+		MaxFindings: 20,
+		ExcludeTags: []string{"dev"},
+		Overrides:   []Override{{Module: "module.dev", AddTags: []string{"dev"}}},
+	})
+	if len(result.Findings) != 1 || result.Findings[0].Module != "module.prod" {
+		t.Errorf("expected only the prod finding to remain, got %+v", result.Findings)
+	}
+}
+
+func TestAnalyzeRuleSelection(t *testing.T) {
+	p := loadFixture(t, "ecs_scale_down.json")
+	if result := Analyze(p, Options{MaxFindings: 20, DisabledRules: []string{"ecs"}}); len(result.Findings) != 0 {
+		t.Errorf("expected no findings with ecs rule disabled, got %d", len(result.Findings))
+	}
+	if result := Analyze(p, Options{MaxFindings: 20, EnabledRules: []string{"iam-*"}}); len(result.Findings) != 0 {
+		t.Errorf("expected no findings with only iam rules enabled, got %d", len(result.Findings))
+	}
+	if result := Analyze(p, Options{MaxFindings: 20, EnabledRules: []string{"ecs"}}); len(result.Findings) == 0 {
+		t.Error("expected findings with ecs rule enabled")
+	}
+}
+
+func TestAnalyzeBaseline(t *testing.T) {
+	p := loadFixture(t, "ecs_modules.json")
+	all := Analyze(p, Options{MaxFindings: 20})
+	if len(all.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(all.Findings))
+	}
+
+	// Accept only the dev finding.
+	var dev []Finding
+	for _, f := range all.Findings {
+		if f.Module == "module.dev" {
+			dev = append(dev, f)
+		}
+	}
+	result := Analyze(p, Options{MaxFindings: 20, Baseline: NewBaseline(dev)})
+	if len(result.Findings) != 1 || result.Findings[0].Module != "module.prod" {
+		t.Errorf("expected only the prod finding to be new, got %+v", result.Findings)
+	}
+	if len(result.ExistingFindings) != 1 || result.ExistingFindings[0].Module != "module.dev" {
+		t.Errorf("expected the dev finding to be existing, got %+v", result.ExistingFindings)
+	}
+
+	// A fully accepted plan has no overall severity.
+	result = Analyze(p, Options{MaxFindings: 20, Baseline: NewBaseline(all.Findings)})
+	if len(result.Findings) != 0 || result.OverallSeverity != 0 {
+		t.Errorf("expected no new findings, got %d (severity %s)", len(result.Findings), result.OverallSeverity)
+	}
+}
+
+func TestFingerprintIgnoresSeverity(t *testing.T) {
+	p := loadFixture(t, "ecs_scale_down.json")
+	plain := Analyze(p, Options{MaxFindings: 20})
+	overridden := Analyze(p, Options{MaxFindings: 20, Overrides: []Override{{Rule: "ecs", Severity: "high"}}})
+	if plain.Findings[0].Fingerprint == "" || plain.Findings[0].Fingerprint != overridden.Findings[0].Fingerprint {
+		t.Errorf("expected stable fingerprint across overrides, got %q vs %q",
+			plain.Findings[0].Fingerprint, overridden.Findings[0].Fingerprint)
+	}
+}
+
+func TestAnalyzeRiskScore(t *testing.T) {
+	p := loadFixture(t, "generic_replace.json")
+	result := Analyze(p, Options{MaxFindings: 20})
+	if len(result.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(result.Findings))
+	}
+	// (high 50 + downtime 10) × replace 1.5 = 90
+	f := result.Findings[0]
+	if f.Score != 90 {
+		t.Errorf("expected score 90, got %d (%v)", f.Score, f.ScoreBreakdown)
+	}
+	if result.Score != 90 {
+		t.Errorf("expected plan score 90, got %d", result.Score)
+	}
+	if len(f.ScoreBreakdown) != 3 || f.ScoreBreakdown[2].Effect != "×1.5" {
+		t.Errorf("unexpected breakdown: %v", f.ScoreBreakdown)
+	}
+}
+
+func TestAnalyzeRiskScoreBlastRadius(t *testing.T) {
+	p := loadFixture(t, "instances_replace.json")
+	result := Analyze(p, Options{MaxFindings: 1})
+	// Each instance: 90 + 5 × 2 siblings = 100; plan score sums all three
+	// findings even though only one is reported.
+	if result.Findings[0].Score != 100 {
+		t.Errorf("expected per-finding score 100, got %d (%v)", result.Findings[0].Score, result.Findings[0].ScoreBreakdown)
+	}
+	if result.Score != 300 {
+		t.Errorf("expected plan score 300, got %d", result.Score)
+	}
+}
+
+func TestAnalyzeRiskScoreCustomWeights(t *testing.T) {
+	p := loadFixture(t, "generic_replace.json")
+	zero := 0.0
+	result := Analyze(p, Options{
+		MaxFindings: 20,
+		Scoring: &ScoreWeights{
+			Severity:    map[string]float64{"high": 100},
+			Actions:     map[string]float64{"replace": 2},
+			Criticality: map[string]float64{"aws_instance": 3},
+			BlastRadius: &zero,
+		},
+	})
+	// (100 + downtime 10) × 2 × 3 = 660
+	if result.Score != 660 {
+		t.Errorf("expected plan score 660, got %d (%v)", result.Score, result.Findings[0].ScoreBreakdown)
+	}
+}
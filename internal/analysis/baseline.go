@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// baselineVersion is bumped whenever the fingerprint scheme changes.
+const baselineVersion = 1
+
+// Baseline records the fingerprints of accepted, pre-existing findings.
+// Findings matching an entry are reported separately and do not count
+// toward the CI threshold.
+type Baseline struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+
+	index map[string]bool
+}
+
+// BaselineEntry identifies one accepted finding. Rule, address and title are
+// stored alongside the fingerprint to keep the file reviewable in diffs.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	Address     string `json:"address"`
+	Title       string `json:"title"`
+}
+
+// Fingerprint returns a stable identifier for a finding. It deliberately
+// excludes severity, tags and the Why lines so that overrides or unrelated
+// attribute changes do not turn an accepted finding into a new one.
+func Fingerprint(f Finding) string {
+	h := sha256.Sum256([]byte(f.RuleID + "\x00" + f.Address + "\x00" + f.Title))
+	return hex.EncodeToString(h[:8])
+}
+
+// NewBaseline builds a baseline from findings, sorted by address, rule and
+// fingerprint so that regenerating it produces minimal diffs.
+func NewBaseline(findings []Finding) *Baseline {
+	seen := make(map[string]bool)
+	entries := make([]BaselineEntry, 0, len(findings))
+	for _, f := range findings {
+		fp := Fingerprint(f)
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		entries = append(entries, BaselineEntry{
+			Fingerprint: fp,
+			Rule:        f.RuleID,
+			Address:     f.Address,
+			Title:       f.Title,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	return &Baseline{Version: baselineVersion, Findings: entries}
+}
+
+// LoadBaseline reads a baseline file written by WriteBaseline.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing baseline %s: %w", path, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("baseline %s has unsupported version %d (expected %d)", path, b.Version, baselineVersion)
+	}
+	return &b, nil
+}
+
+// WriteBaseline writes the baseline as indented JSON.
+func WriteBaseline(w io.Writer, b *Baseline) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(b); err != nil {
+		return fmt.Errorf("encoding baseline: %w", err)
+	}
+	return nil
+}
+
+// Contains reports whether the finding is recorded in the baseline.
+func (b *Baseline) Contains(f Finding) bool {
+	if b == nil {
+		return false
+	}
+	if b.index == nil {
+		b.index = make(map[string]bool, len(b.Findings))
+		for _, e := range b.Findings {
+			b.index[e.Fingerprint] = true
+		}
+	}
+	return b.index[f.Fingerprint]
+}
@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// Override adjusts the severity and tags of findings that match all of its
+// non-empty selectors. Selectors are glob patterns ("*" and "?").
+type Override struct {
+	Rule         string   `json:"rule,omitempty"`
+	ResourceType string   `json:"resource_type,omitempty"`
+	Address      string   `json:"address,omitempty"`
+	Module       string   `json:"module,omitempty"`
+	Severity     string   `json:"severity,omitempty"`
+	AddTags      []string `json:"add_tags,omitempty"`
+	RemoveTags   []string `json:"remove_tags,omitempty"`
+}
+
+// Validate checks that the override selects something and changes something.
+func (o Override) Validate() error {
+	if o.Rule == "" && o.ResourceType == "" && o.Address == "" && o.Module == "" {
+		return fmt.Errorf("override needs at least one of rule, resource_type, address or module")
+	}
+	if o.Severity == "" && len(o.AddTags) == 0 && len(o.RemoveTags) == 0 {
+		return fmt.Errorf("override needs at least one of severity, add_tags or remove_tags")
+	}
+	if o.Severity != "" && !ValidSeverity(o.Severity) {
+		return fmt.Errorf("override has invalid severity %q (use low, medium or high)", o.Severity)
+	}
+	return nil
+}
+
+// Matches reports whether the override applies to the finding.
+func (o Override) Matches(f Finding) bool {
+	if o.Rule != "" && !util.MatchGlob(o.Rule, f.RuleID) {
+		return false
+	}
+	if o.ResourceType != "" && !util.MatchGlob(o.ResourceType, f.ResourceType) {
+		return false
+	}
+	if o.Address != "" && !util.MatchGlob(o.Address, f.Address) {
+		return false
+	}
+	if o.Module != "" && !matchModule(o.Module, f.Module) {
+		return false
+	}
+	return true
+}
+
+// matchModule matches a module path pattern against a module address.
+// A pattern also matches any child module of the modules it selects, so
+// "module.prod" covers "module.prod.module.db".
+func matchModule(pattern, module string) bool {
+	if module == "" {
+		return false
+	}
+	if util.MatchGlob(pattern, module) {
+		return true
+	}
+	return util.MatchGlob(pattern+".module.*", module)
+}
+
+// applyOverrides applies overrides in order, so later entries win.
+// Each finding keeps its original severity for auditing.
+func applyOverrides(findings []Finding, overrides []Override) {
+	for i := range findings {
+		f := &findings[i]
+		for _, o := range overrides {
+			if !o.Matches(*f) {
+				continue
+			}
+			if o.Severity != "" {
+				f.Severity = ParseSeverity(o.Severity)
+			}
+			f.Tags = adjustTags(f.Tags, o.AddTags, o.RemoveTags)
+		}
+	}
+}
+
+func adjustTags(tags, add, remove []string) []string {
+	if len(add) == 0 && len(remove) == 0 {
+		return tags
+	}
+	result := make([]string, 0, len(tags)+len(add))
+	for _, t := range tags {
+		if !containsStr(remove, t) {
+			result = append(result, t)
+		}
+	}
+	for _, t := range add {
+		if !containsStr(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// ValidSeverity reports whether s names a known severity level.
+func ValidSeverity(s string) bool {
+	switch strings.ToLower(s) {
+	case "low", "medium", "high":
+		return true
+	}
+	return false
+}
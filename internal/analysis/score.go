@@ -0,0 +1,216 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// ScoreWeights configures the numeric risk score. A finding's score is
+//
+//	(severity points + sum of tag points) × action multiplier × criticality multiplier
+//	  + min(blast radius cap, blast radius points × (instances - 1))
+//
+// rounded to the nearest integer. The plan score is the sum of the scores of
+// all new (non-baseline) findings. Weights given in configuration are merged
+// over DefaultScoreWeights key by key.
+type ScoreWeights struct {
+	Severity       map[string]float64 `json:"severity,omitempty"`    // low/medium/high → base points
+	Tags           map[string]float64 `json:"tags,omitempty"`        // tag → added points
+	Actions        map[string]float64 `json:"actions,omitempty"`     // create/update/delete/replace → multiplier
+	Criticality    map[string]float64 `json:"criticality,omitempty"` // resource type glob → multiplier
+	BlastRadius    *float64           `json:"blast_radius_per_instance,omitempty"`
+	BlastRadiusCap *float64           `json:"blast_radius_cap,omitempty"`
+}
+
+// ScoreFactor is one step of a finding's score derivation.
+type ScoreFactor struct {
+	Factor string `json:"factor"`
+	Effect string `json:"effect"`
+}
+
+// DefaultScoreWeights returns the built-in weights.
+func DefaultScoreWeights() ScoreWeights {
+	blast, blastCap := 5.0, 50.0
+	return ScoreWeights{
+		Severity: map[string]float64{"low": 10, "medium": 25, "high": 50},
+		Tags: map[string]float64{
+			"security": 15,
+			"data":     20,
+			"downtime": 10,
+			"network":  5,
+			"ops":      5,
+			"capacity": 5,
+		},
+		Actions: map[string]float64{
+			"create":  1.0,
+			"update":  1.0,
+			"delete":  1.5,
+			"replace": 1.5,
+		},
+		Criticality: map[string]float64{
+			"aws_db_*":       1.5,
+			"aws_rds_*":      1.5,
+			"aws_dynamodb_*": 1.5,
+			"aws_kms_*":      1.3,
+			"aws_iam_*":      1.3,
+		},
+		BlastRadius:    &blast,
+		BlastRadiusCap: &blastCap,
+	}
+}
+
+// Merge returns w with the entries of other layered on top.
+func (w ScoreWeights) Merge(other ScoreWeights) ScoreWeights {
+	w.Severity = mergeWeights(w.Severity, other.Severity)
+	w.Tags = mergeWeights(w.Tags, other.Tags)
+	w.Actions = mergeWeights(w.Actions, other.Actions)
+	w.Criticality = mergeWeights(w.Criticality, other.Criticality)
+	if other.BlastRadius != nil {
+		w.BlastRadius = other.BlastRadius
+	}
+	if other.BlastRadiusCap != nil {
+		w.BlastRadiusCap = other.BlastRadiusCap
+	}
+	return w
+}
+
+// Validate rejects negative weights and unknown severity or action keys.
+func (w ScoreWeights) Validate() error {
+	for k, v := range w.Severity {
+		if !ValidSeverity(k) {
+			return fmt.Errorf("unknown severity %q", k)
+		}
+		if v < 0 {
+			return fmt.Errorf("severity.%s must not be negative", k)
+		}
+	}
+	for k, v := range w.Actions {
+		switch k {
+		case "create", "update", "delete", "replace":
+		default:
+			return fmt.Errorf("unknown action %q", k)
+		}
+		if v < 0 {
+			return fmt.Errorf("actions.%s must not be negative", k)
+		}
+	}
+	for k, v := range w.Tags {
+		if v < 0 {
+			return fmt.Errorf("tags.%s must not be negative", k)
+		}
+	}
+	for k, v := range w.Criticality {
+		if v < 0 {
+			return fmt.Errorf("criticality.%s must not be negative", k)
+		}
+	}
+	if w.BlastRadius != nil && *w.BlastRadius < 0 {
+		return fmt.Errorf("blast_radius_per_instance must not be negative")
+	}
+	if w.BlastRadiusCap != nil && *w.BlastRadiusCap < 0 {
+		return fmt.Errorf("blast_radius_cap must not be negative")
+	}
+	return nil
+}
+
+func mergeWeights(base, over map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(base)+len(over))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range over {
+		result[k] = v
+	}
+	return result
+}
+
+// scoreFinding computes the score and its breakdown. instances is the number
+// of planned instances of the finding's resource (count/for_each siblings).
+func scoreFinding(f Finding, instances int, w ScoreWeights) (int, []ScoreFactor) {
+	var factors []ScoreFactor
+
+	sev := f.Severity.String()
+	points := w.Severity[sev]
+	factors = append(factors, ScoreFactor{Factor: "severity " + sev, Effect: formatPoints(points)})
+
+	for _, tag := range f.Tags {
+		if tp := w.Tags[tag]; tp != 0 {
+			points += tp
+			factors = append(factors, ScoreFactor{Factor: "tag " + tag, Effect: formatPoints(tp)})
+		}
+	}
+
+	if m, ok := w.Actions[f.Action]; ok && m != 1 {
+		points *= m
+		factors = append(factors, ScoreFactor{Factor: "action " + f.Action, Effect: formatMultiplier(m)})
+	}
+
+	if pattern, m, ok := criticalityFor(f.ResourceType, w.Criticality); ok && m != 1 {
+		points *= m
+		factors = append(factors, ScoreFactor{Factor: "criticality " + pattern, Effect: formatMultiplier(m)})
+	}
+
+	if instances > 1 && w.BlastRadius != nil && *w.BlastRadius != 0 {
+		blast := *w.BlastRadius * float64(instances-1)
+		if w.BlastRadiusCap != nil && blast > *w.BlastRadiusCap {
+			blast = *w.BlastRadiusCap
+		}
+		points += blast
+		factors = append(factors, ScoreFactor{
+			Factor: fmt.Sprintf("blast radius %d instances", instances),
+			Effect: formatPoints(blast),
+		})
+	}
+
+	return int(math.Round(points)), factors
+}
+
+// criticalityFor returns the most specific (longest) matching pattern.
+func criticalityFor(resourceType string, criticality map[string]float64) (string, float64, bool) {
+	patterns := make([]string, 0, len(criticality))
+	for p := range criticality {
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, p := range patterns {
+		if util.MatchGlob(p, resourceType) {
+			return p, criticality[p], true
+		}
+	}
+	return "", 0, false
+}
+
+// instanceKey strips a trailing count/for_each index from an address so that
+// all instances of one resource block share a key.
+func instanceKey(address string) string {
+	if strings.HasSuffix(address, "]") {
+		if i := strings.LastIndex(address, "["); i > 0 {
+			return address[:i]
+		}
+	}
+	return address
+}
+
+func formatPoints(p float64) string {
+	return "+" + trimFloat(p)
+}
+
+func formatMultiplier(m float64) string {
+	return "×" + trimFloat(m)
+}
+
+// trimFloat formats with at most two decimals and no trailing zeros.
+func trimFloat(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,196 @@
+package compare
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"github.com/djeeteg007/tf-why/internal/analysis"
+	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// maxAttributeDiffs caps the planned-value differences reported per resource.
+const maxAttributeDiffs = 20
+
+// Report describes what changed between two plans of the same configuration.
+type Report struct {
+	OldSummary  analysis.Summary  `json:"old_summary"`
+	NewSummary  analysis.Summary  `json:"new_summary"`
+	OldSeverity analysis.Severity `json:"old_severity"`
+	NewSeverity analysis.Severity `json:"new_severity"`
+
+	Resources []ResourceDelta `json:"resources"`
+
+	AddedFindings   []analysis.Finding `json:"added_findings"`
+	RemovedFindings []analysis.Finding `json:"removed_findings"`
+	ChangedFindings []FindingChange    `json:"changed_findings"`
+}
+
+// ResourceDelta is a resource whose planned action or planned values differ.
+// OldAction/NewAction are "absent" when the resource is not in that plan.
+type ResourceDelta struct {
+	Address    string      `json:"address"`
+	Type       string      `json:"type"`
+	OldAction  string      `json:"old_action"`
+	NewAction  string      `json:"new_action"`
+	Attributes []util.Diff `json:"attributes,omitempty"`
+}
+
+// ActionChanged reports whether the planned action differs between plans.
+func (d ResourceDelta) ActionChanged() bool {
+	return d.OldAction != d.NewAction
+}
+
+// FindingChange is a finding present in both plans with a different severity.
+type FindingChange struct {
+	Old analysis.Finding `json:"old"`
+	New analysis.Finding `json:"new"`
+}
+
+// Empty reports whether the two plans are equivalent for review purposes.
+func (r Report) Empty() bool {
+	return len(r.Resources) == 0 && len(r.AddedFindings) == 0 &&
+		len(r.RemovedFindings) == 0 && len(r.ChangedFindings) == 0
+}
+
+// Plans analyzes both plans with the same options and reports the
+// differences. MaxFindings is ignored so that truncation cannot make a
+// finding look added or removed.
+func Plans(oldPlan, newPlan *plan.Plan, opts analysis.Options) Report {
+	opts.MaxFindings = math.MaxInt
+	opts.Baseline = nil
+	oldResult := analysis.Analyze(oldPlan, opts)
+	newResult := analysis.Analyze(newPlan, opts)
+
+	report := Report{
+		OldSummary:  oldResult.Summary,
+		NewSummary:  newResult.Summary,
+		OldSeverity: oldResult.OverallSeverity,
+		NewSeverity: newResult.OverallSeverity,
+		Resources:   compareResources(oldPlan, newPlan, opts.OnlyTypes),
+	}
+	report.AddedFindings, report.RemovedFindings, report.ChangedFindings =
+		compareFindings(oldResult.Findings, newResult.Findings)
+	return report
+}
+
+func compareResources(oldPlan, newPlan *plan.Plan, onlyTypes []string) []ResourceDelta {
+	oldByAddr := indexChanges(oldPlan)
+	newByAddr := indexChanges(newPlan)
+
+	addrs := make([]string, 0, len(oldByAddr)+len(newByAddr))
+	for a := range oldByAddr {
+		addrs = append(addrs, a)
+	}
+	for a := range newByAddr {
+		if _, ok := oldByAddr[a]; !ok {
+			addrs = append(addrs, a)
+		}
+	}
+	sort.Strings(addrs)
+
+	var deltas []ResourceDelta
+	for _, addr := range addrs {
+		oldRC, inOld := oldByAddr[addr]
+		newRC, inNew := newByAddr[addr]
+
+		d := ResourceDelta{Address: addr, OldAction: "absent", NewAction: "absent"}
+		if inOld {
+			d.Type = oldRC.Type
+			d.OldAction = oldRC.Change.Actions.ActionType().String()
+		}
+		if inNew {
+			d.Type = newRC.Type
+			d.NewAction = newRC.Change.Actions.ActionType().String()
+		}
+		if len(onlyTypes) > 0 && !containsStr(onlyTypes, d.Type) {
+			continue
+		}
+
+		if inOld && inNew {
+			d.Attributes = util.ExtractDiffs(
+				oldRC.Change.After, newRC.Change.After,
+				mergeMarkers(oldRC.Change.AfterSensitive, newRC.Change.AfterSensitive),
+				newRC.Change.AfterUnknown, maxAttributeDiffs,
+			)
+		}
+		if d.ActionChanged() || len(d.Attributes) > 0 {
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
+
+func indexChanges(p *plan.Plan) map[string]plan.ResourceChange {
+	m := make(map[string]plan.ResourceChange, len(p.ResourceChanges))
+	for _, rc := range p.ResourceChanges {
+		m[rc.Address] = rc
+	}
+	return m
+}
+
+// mergeMarkers unions two *_sensitive markers so that a value sensitive in
+// either plan is masked.
+func mergeMarkers(a, b json.RawMessage) json.RawMessage {
+	var aBool, bBool bool
+	if json.Unmarshal(a, &aBool) == nil && aBool {
+		return a
+	}
+	if json.Unmarshal(b, &bBool) == nil && bBool {
+		return b
+	}
+
+	var aMap, bMap map[string]json.RawMessage
+	_ = json.Unmarshal(a, &aMap)
+	_ = json.Unmarshal(b, &bMap)
+	if len(aMap) == 0 {
+		return b
+	}
+	if len(bMap) == 0 {
+		return a
+	}
+	merged := make(map[string]json.RawMessage, len(aMap)+len(bMap))
+	for k, v := range aMap {
+		merged[k] = v
+	}
+	for k, v := range bMap {
+		merged[k] = v
+	}
+	out, _ := json.Marshal(merged)
+	return out
+}
+
+// compareFindings matches findings by fingerprint.
+func compareFindings(oldFindings, newFindings []analysis.Finding) (added, removed []analysis.Finding, changed []FindingChange) {
+	oldByFP := make(map[string]analysis.Finding, len(oldFindings))
+	for _, f := range oldFindings {
+		oldByFP[f.Fingerprint] = f
+	}
+	newByFP := make(map[string]bool, len(newFindings))
+
+	for _, f := range newFindings {
+		newByFP[f.Fingerprint] = true
+		old, ok := oldByFP[f.Fingerprint]
+		if !ok {
+			added = append(added, f)
+		} else if old.Severity != f.Severity {
+			changed = append(changed, FindingChange{Old: old, New: f})
+		}
+	}
+	for _, f := range oldFindings {
+		if !newByFP[f.Fingerprint] {
+			removed = append(removed, f)
+		}
+	}
+	return added, removed, changed
+}
+
+func containsStr(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/djeeteg007/tf-why/internal/analysis"
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+func loadFixture(t *testing.T, name string) *plan.Plan {
+	t.Helper()
+	path := filepath.Join("..", "..", "testdata", name)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("cannot open fixture %s: %v", name, err)
+	}
+	defer f.Close()
+	p, err := plan.Parse(f)
+	if err != nil {
+		t.Fatalf("cannot parse fixture %s: %v", name, err)
+	}
+	return p
+}
+
+func TestComparePlans(t *testing.T) {
+	oldPlan := loadFixture(t, "rds_minor_upgrade.json")
+	newPlan := loadFixture(t, "rds_replace.json")
+	r := Plans(oldPlan, newPlan, analysis.Options{})
+
+	if r.OldSeverity != analysis.SeverityMedium || r.NewSeverity != analysis.SeverityHigh {
+		t.Errorf("expected MEDIUM → HIGH, got %s → %s", r.OldSeverity, r.NewSeverity)
+	}
+	if len(r.Resources) != 1 {
+		t.Fatalf("expected 1 changed resource, got %d", len(r.Resources))
+	}
+	d := r.Resources[0]
+	if d.OldAction != "update" || d.NewAction != "replace" || !d.ActionChanged() {
+		t.Errorf("expected update → replace, got %s → %s", d.OldAction, d.NewAction)
+	}
+	if len(d.Attributes) == 0 || d.Attributes[0].Path != "engine_version" {
+		t.Errorf("expected engine_version attribute diff, got %v", d.Attributes)
+	}
+	if len(r.AddedFindings) == 0 || len(r.RemovedFindings) == 0 {
+		t.Errorf("expected added and removed findings, got +%d -%d", len(r.AddedFindings), len(r.RemovedFindings))
+	}
+}
+
+func TestCompareIdenticalPlans(t *testing.T) {
+	p := loadFixture(t, "iam_wildcard.json")
+	r := Plans(p, p, analysis.Options{})
+	if !r.Empty() {
+		t.Errorf("expected empty report for identical plans, got %+v", r)
+	}
+}
+
+func TestCompareSeverityChange(t *testing.T) {
+	p := loadFixture(t, "ecs_scale_down.json")
+	// Emulate a re-plan that raised severity by analyzing with an override.
+	oldFindings := analysis.Analyze(p, analysis.Options{}).Findings
+	newFindings := analysis.Analyze(p, analysis.Options{
+		Overrides: []analysis.Override{{Rule: "ecs", Address: "*", Severity: "high"}},
+	}).Findings
+	added, removed, changed := compareFindings(oldFindings, newFindings)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("expected no added/removed findings, got +%d -%d", len(added), len(removed))
+	}
+	if len(changed) != len(newFindings) || changed[0].Old.Severity != analysis.SeverityMedium {
+		t.Errorf("expected every finding to change severity, got %+v", changed)
+	}
+}
+
+func TestCompareMasksSensitive(t *testing.T) {
+	p := loadFixture(t, "sensitive_unknown.json")
+	altered := *p
+	altered.ResourceChanges = append([]plan.ResourceChange(nil), p.ResourceChanges...)
+	rc := altered.ResourceChanges[0]
+	rc.Change.After = []byte(`{"user_data":"different-secret"}`)
+	rc.Change.AfterSensitive = []byte(`{}`)
+	altered.ResourceChanges[0] = rc
+
+	r := Plans(p, &altered, analysis.Options{})
+	for _, d := range r.Resources {
+		for _, a := range d.Attributes {
+			if a.Path == "user_data" && (a.After != "<sensitive>" || a.Before != "<sensitive>") {
+				t.Errorf("expected user_data to stay masked, got %s", a)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/djeeteg007/tf-why/internal/analysis"
+	"github.com/djeeteg007/tf-why/internal/rules"
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// DefaultFile is the configuration file looked up in the working directory
+// when --config is not given.
+const DefaultFile = ".tf-why.json"
+
+// Config is the on-disk tf-why configuration. Top-level settings apply to
+// every run; a selected profile is layered on top of them.
+// Rules starts from rules.DefaultConfig(); lists given in the file replace
+// the defaults and maps are merged into them.
+type Config struct {
+	Settings
+	Rules    rules.Config           `json:"rules"`
+	Scoring  *analysis.ScoreWeights `json:"scoring,omitempty"`
+	Profiles map[string]Profile     `json:"profiles,omitempty"`
+}
+
+// Settings are the gate settings shared by the top level and profiles.
+type Settings struct {
+	FailOn        string              `json:"fail_on,omitempty"`
+	FailScore     int                 `json:"fail_score,omitempty"`
+	EnabledRules  []string            `json:"enabled_rules,omitempty"`
+	DisabledRules []string            `json:"disabled_rules,omitempty"`
+	ExcludeTags   []string            `json:"exclude_tags,omitempty"`
+	Overrides     []analysis.Override `json:"overrides,omitempty"`
+}
+
+// Profile is a named set of settings for one environment. It is selected
+// explicitly by name, or automatically when TF_WORKSPACE or the Terraform
+// directory matches one of its glob patterns.
+type Profile struct {
+	Settings
+	Workspaces []string `json:"workspaces,omitempty"`
+	Dirs       []string `json:"dirs,omitempty"`
+}
+
+// Load reads and validates the configuration file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return parse(data, path)
+}
+
+// LoadDefault loads DefaultFile if it exists. It returns an empty
+// configuration when the file is absent.
+func LoadDefault() (*Config, error) {
+	data, err := os.ReadFile(DefaultFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{Rules: rules.DefaultConfig()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return parse(data, DefaultFile)
+}
+
+func parse(data []byte, path string) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	cfg := Config{Rules: rules.DefaultConfig()}
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks the top-level settings and every profile.
+func (c *Config) Validate() error {
+	if err := c.Settings.validate(); err != nil {
+		return err
+	}
+	if err := c.Rules.Validate(); err != nil {
+		return fmt.Errorf("rules: %w", err)
+	}
+	if c.Scoring != nil {
+		if err := c.Scoring.Validate(); err != nil {
+			return fmt.Errorf("scoring: %w", err)
+		}
+	}
+	for _, name := range c.profileNames() {
+		p := c.Profiles[name]
+		if err := p.Settings.validate(); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (s Settings) validate() error {
+	if s.FailOn != "" && !analysis.ValidSeverity(s.FailOn) {
+		return fmt.Errorf("invalid fail_on %q (use low, medium or high)", s.FailOn)
+	}
+	if s.FailScore < 0 {
+		return fmt.Errorf("fail_score must not be negative")
+	}
+	for i, o := range s.Overrides {
+		if err := o.Validate(); err != nil {
+			return fmt.Errorf("overrides[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Selection describes which profile was chosen and why.
+type Selection struct {
+	Name   string // empty when no profile applies
+	Source string // "--profile", "TF_WORKSPACE" or "--dir"
+}
+
+// SelectProfile picks a profile. An explicit name wins and must exist;
+// otherwise the workspace is matched against profile names and workspace
+// patterns, then dir against dir patterns. Profiles are tried in name order
+// so that the choice is deterministic.
+func (c *Config) SelectProfile(name, workspace, dir string) (Selection, error) {
+	if name != "" {
+		if _, ok := c.Profiles[name]; !ok {
+			return Selection{}, fmt.Errorf("unknown profile %q", name)
+		}
+		return Selection{Name: name, Source: "--profile"}, nil
+	}
+
+	names := c.profileNames()
+	if workspace != "" {
+		for _, n := range names {
+			if n == workspace || matchAny(c.Profiles[n].Workspaces, workspace) {
+				return Selection{Name: n, Source: "TF_WORKSPACE"}, nil
+			}
+		}
+	}
+
+	if dir != "" {
+		candidates := []string{filepath.ToSlash(filepath.Clean(dir))}
+		if abs, err := filepath.Abs(dir); err == nil {
+			candidates = append(candidates, filepath.ToSlash(abs))
+		}
+		for _, n := range names {
+			for _, cand := range candidates {
+				if matchAny(c.Profiles[n].Dirs, cand) {
+					return Selection{Name: n, Source: "--dir"}, nil
+				}
+			}
+		}
+	}
+
+	return Selection{}, nil
+}
+
+// Effective returns the top-level settings with the named profile layered
+// on top: scalar values and enabled_rules are replaced when the profile sets
+// them, lists of exclusions and overrides are appended.
+func (c *Config) Effective(profile string) Settings {
+	s := c.Settings
+	p, ok := c.Profiles[profile]
+	if !ok {
+		return s
+	}
+	if p.FailOn != "" {
+		s.FailOn = p.FailOn
+	}
+	if p.FailScore != 0 {
+		s.FailScore = p.FailScore
+	}
+	if len(p.EnabledRules) > 0 {
+		s.EnabledRules = p.EnabledRules
+	}
+	s.DisabledRules = concat(s.DisabledRules, p.DisabledRules)
+	s.ExcludeTags = concat(s.ExcludeTags, p.ExcludeTags)
+	s.Overrides = concat(s.Overrides, p.Overrides)
+	return s
+}
+
+func (c *Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if util.MatchGlob(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// concat returns a new slice so that callers never alias config storage.
+func concat[T any](a, b []T) []T {
+	result := make([]T, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/djeeteg007/tf-why/internal/rules"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tf-why.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := writeConfig(t, `{
+		"overrides": [
+			{"rule": "ecs", "module": "module.prod", "severity": "high"},
+			{"resource_type": "aws_ecs_*", "address": "module.dev.*", "severity": "low", "add_tags": ["dev"]}
+		]
+	}`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Overrides) != 2 {
+		t.Fatalf("expected 2 overrides, got %d", len(cfg.Overrides))
+	}
+	if cfg.Overrides[1].AddTags[0] != "dev" {
+		t.Errorf("expected add_tags to be decoded, got %v", cfg.Overrides[1].AddTags)
+	}
+}
+
+func TestLoadRejectsInvalidOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"no selector", `{"overrides":[{"severity":"high"}]}`, "at least one of rule"},
+		{"no effect", `{"overrides":[{"rule":"ecs"}]}`, "at least one of severity"},
+		{"bad severity", `{"overrides":[{"rule":"ecs","severity":"urgent"}]}`, "invalid severity"},
+		{"unknown field", `{"overides":[]}`, "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+const profilesConfig = `{
+	"fail_on": "high",
+	"exclude_tags": ["cost"],
+	"overrides": [{"rule": "ecs", "severity": "medium"}],
+	"profiles": {
+		"dev": {
+			"fail_on": "high",
+			"disabled_rules": ["ecs"],
+			"dirs": ["*/envs/dev"]
+		},
+		"prod": {
+			"fail_on": "medium",
+			"enabled_rules": ["iam-*", "ecs"],
+			"exclude_tags": ["ops"],
+			"overrides": [{"rule": "ecs", "severity": "high"}],
+			"workspaces": ["prod-*"],
+			"dirs": ["*/envs/prod"]
+		}
+	}
+}`
+
+func TestSelectProfile(t *testing.T) {
+	cfg, err := Load(writeConfig(t, profilesConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name, explicit, workspace, dir string
+		want                           Selection
+	}{
+		{"explicit", "dev", "prod", "infra/envs/prod", Selection{Name: "dev", Source: "--profile"}},
+		{"workspace by name", "", "prod", "", Selection{Name: "prod", Source: "TF_WORKSPACE"}},
+		{"workspace by pattern", "", "prod-eu", "", Selection{Name: "prod", Source: "TF_WORKSPACE"}},
+		{"dir", "", "default", "infra/envs/dev", Selection{Name: "dev", Source: "--dir"}},
+		{"none", "", "staging", "infra/envs/staging", Selection{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.SelectProfile(tt.explicit, tt.workspace, tt.dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := cfg.SelectProfile("qa", "", ""); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestEffectiveSettings(t *testing.T) {
+	cfg, err := Load(writeConfig(t, profilesConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prod := cfg.Effective("prod")
+	if prod.FailOn != "medium" {
+		t.Errorf("expected profile fail_on to win, got %q", prod.FailOn)
+	}
+	if len(prod.EnabledRules) != 2 {
+		t.Errorf("expected profile enabled_rules, got %v", prod.EnabledRules)
+	}
+	if len(prod.ExcludeTags) != 2 || prod.ExcludeTags[0] != "cost" || prod.ExcludeTags[1] != "ops" {
+		t.Errorf("expected exclude_tags to be appended, got %v", prod.ExcludeTags)
+	}
+	if len(prod.Overrides) != 2 || prod.Overrides[1].Severity != "high" {
+		t.Errorf("expected profile overrides after top-level ones, got %v", prod.Overrides)
+	}
+
+	if base := cfg.Effective(""); base.FailOn != "high" || len(base.Overrides) != 1 {
+		t.Errorf("expected top-level settings without profile, got %+v", base)
+	}
+}
+
+func TestLoadScoring(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"fail_score": 200, "scoring": {"tags": {"cost": 3}, "blast_radius_cap": 20}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.FailScore != 200 || cfg.Scoring.Tags["cost"] != 3 || *cfg.Scoring.BlastRadiusCap != 20 {
+		t.Errorf("unexpected scoring config: %+v", cfg)
+	}
+
+	if _, err := Load(writeConfig(t, `{"scoring": {"severity": {"critical": 90}}}`)); err == nil {
+		t.Error("expected error for unknown severity weight")
+	}
+	if _, err := Load(writeConfig(t, `{"scoring": {"actions": {"replace": -1}}}`)); err == nil {
+		t.Error("expected error for negative multiplier")
+	}
+}
+
+func TestLoadRuleConfig(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"rules": {"trusted_accounts": ["111111111111"]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Rules.TrustedAccounts) != 1 || cfg.Rules.TrustedAccounts[0] != "111111111111" {
+		t.Errorf("expected trusted_accounts to be decoded, got %v", cfg.Rules.TrustedAccounts)
+	}
+}
+
+func TestLoadRuleConfigSensitivePorts(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"rules": {"sg_sensitive_ports": {"8443": "Admin UI", "22": ""}, "sg_wide_port_range": 0}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ports := cfg.Rules.SGSensitivePorts
+	if ports["8443"] != "Admin UI" || ports["3389"] != "RDP" || ports["22"] != "" {
+		t.Errorf("expected sg_sensitive_ports merged over defaults, got %v", ports)
+	}
+	if cfg.Rules.SGWidePortRange != 0 || cfg.Rules.SGBroadPrefixIPv4 != 8 {
+		t.Errorf("expected explicit 0 to disable and unset values to keep defaults, got %+v", cfg.Rules)
+	}
+
+	if _, err := Load(writeConfig(t, `{"rules": {"sg_sensitive_ports": {"22-20": "bad"}}}`)); err == nil {
+		t.Error("expected error for invalid port range")
+	}
+}
+
+func TestLoadRuleConfigHighRiskManagedPolicies(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"rules": {"high_risk_managed_policies": ["arn:aws:iam::aws:policy/ReadOnly*"]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Rules.HighRiskManagedPolicies; len(got) != 1 || got[0] != "arn:aws:iam::aws:policy/ReadOnly*" {
+		t.Errorf("expected high_risk_managed_policies to replace defaults, got %v", got)
+	}
+	if got := rules.DefaultConfig().HighRiskManagedPolicies; got[0] != "arn:aws:iam::aws:policy/AdministratorAccess" {
+		t.Errorf("expected loading a config to leave the defaults unchanged, got %v", got)
+	}
+}
+
+func TestLoadRuleConfigStatefulResources(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"rules": {"stateful_resources": {
+		"aws_sqs_queue": null,
+		"aws_memorydb_cluster": {"snapshot_attributes": ["final_snapshot_name"], "recommendations": ["Set final_snapshot_name"]}
+	}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	catalog := cfg.Rules.StatefulResources
+	if catalog["aws_sqs_queue"] != nil || catalog["aws_docdb_cluster"] == nil {
+		t.Errorf("expected stateful_resources merged over defaults with null removing an entry, got %v", catalog)
+	}
+	if m := catalog["aws_memorydb_cluster"]; m == nil || len(m.SnapshotAttributes) != 1 || m.Recommendations[0] != "Set final_snapshot_name" {
+		t.Errorf("expected aws_memorydb_cluster to be added, got %+v", m)
+	}
+}
+
+func TestLoadRuleConfigSensitiveTiers(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `{"rules": {"sg_sensitive_tier_names": ["*-data-*"], "sg_sensitive_tier_tags": {"Layer": "db*"}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := cfg.Rules.SGSensitiveTierNames; len(names) != 1 || names[0] != "*-data-*" {
+		t.Errorf("expected sg_sensitive_tier_names to replace defaults, got %v", names)
+	}
+	if tags := cfg.Rules.SGSensitiveTierTags; tags["Layer"] != "db*" || tags["Tier"] != "data" {
+		t.Errorf("expected sg_sensitive_tier_tags merged over defaults, got %v", tags)
+	}
+}
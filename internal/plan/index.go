@@ -0,0 +1,143 @@
+package plan
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Index provides lookups over a plan's resource changes, prior state and
+// configuration for rules that relate resources to each other.
+type Index struct {
+	Plan *Plan
+
+	byAddress map[string]*ResourceChange
+	byType    map[string][]*ResourceChange
+	byModule  map[string][]*ResourceChange
+	state     map[string]*StateResource
+	stateAll  []*StateResource
+	stateType map[string][]*StateResource
+	config    map[string]*ConfigResource
+}
+
+// NewIndex indexes p. Lists keep plan order.
+func NewIndex(p *Plan) *Index {
+	x := &Index{
+		Plan:      p,
+		byAddress: make(map[string]*ResourceChange),
+		byType:    make(map[string][]*ResourceChange),
+		byModule:  make(map[string][]*ResourceChange),
+		state:     make(map[string]*StateResource),
+		stateType: make(map[string][]*StateResource),
+		config:    make(map[string]*ConfigResource),
+	}
+	for i := range p.ResourceChanges {
+		rc := &p.ResourceChanges[i]
+		x.byAddress[rc.Address] = rc
+		x.byType[rc.Type] = append(x.byType[rc.Type], rc)
+		x.byModule[rc.ModuleAddress] = append(x.byModule[rc.ModuleAddress], rc)
+	}
+	if p.PriorState != nil && p.PriorState.Values != nil {
+		x.indexState(&p.PriorState.Values.RootModule)
+	}
+	if p.Configuration != nil {
+		x.indexConfig(&p.Configuration.RootModule, "")
+	}
+	return x
+}
+
+func (x *Index) indexState(m *StateModule) {
+	for i := range m.Resources {
+		r := &m.Resources[i]
+		x.state[r.Address] = r
+		x.stateAll = append(x.stateAll, r)
+		x.stateType[r.Type] = append(x.stateType[r.Type], r)
+	}
+	for i := range m.ChildModules {
+		x.indexState(&m.ChildModules[i])
+	}
+}
+
+// indexConfig records resources under their module-qualified address
+// (e.g. "module.db.aws_db_instance.main"), which has no instance keys.
+func (x *Index) indexConfig(m *ConfigModule, prefix string) {
+	for i := range m.Resources {
+		r := &m.Resources[i]
+		x.config[prefix+r.Address] = r
+	}
+	for name, call := range m.ModuleCalls {
+		x.indexConfig(&call.Module, prefix+"module."+name+".")
+	}
+}
+
+// Change returns the resource change at address.
+func (x *Index) Change(address string) (*ResourceChange, bool) {
+	rc, ok := x.byAddress[address]
+	return rc, ok
+}
+
+// ByType returns the resource changes of a resource type.
+func (x *Index) ByType(resourceType string) []*ResourceChange {
+	return x.byType[resourceType]
+}
+
+// ByModule returns the resource changes in a module; "" is the root module.
+func (x *Index) ByModule(module string) []*ResourceChange {
+	return x.byModule[module]
+}
+
+// StateResource returns the prior state of the resource at address.
+func (x *Index) StateResource(address string) (*StateResource, bool) {
+	r, ok := x.state[address]
+	return r, ok
+}
+
+// StateResources returns every resource in the prior state.
+func (x *Index) StateResources() []*StateResource {
+	return x.stateAll
+}
+
+// StateByType returns the prior state resources of a resource type,
+// including those the plan leaves unchanged.
+func (x *Index) StateByType(resourceType string) []*StateResource {
+	return x.stateType[resourceType]
+}
+
+// ConfigResource returns the configuration block of the resource at
+// address. Instance keys ("[0]", "[\"a\"]") are ignored.
+func (x *Index) ConfigResource(address string) (*ConfigResource, bool) {
+	r, ok := x.config[configAddress(address)]
+	return r, ok
+}
+
+// References returns the references of a configured attribute, e.g.
+// ["aws_kms_key.main.arn", "aws_kms_key.main"] for kms_key_id.
+func (x *Index) References(address, attribute string) []string {
+	r, ok := x.ConfigResource(address)
+	if !ok {
+		return nil
+	}
+	var expr struct {
+		References []string `json:"references"`
+	}
+	if err := json.Unmarshal(r.Expressions[attribute], &expr); err != nil {
+		return nil
+	}
+	return expr.References
+}
+
+// configAddress strips instance keys from a resource address.
+func configAddress(address string) string {
+	var b strings.Builder
+	depth := 0
+	for _, c := range address {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "kms_in_use.json"))
+	if err != nil {
+		t.Fatalf("cannot open fixture: %v", err)
+	}
+	defer f.Close()
+	p, err := Parse(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	x := NewIndex(p)
+
+	if rc, ok := x.Change("aws_kms_key.data"); !ok || rc.Type != "aws_kms_key" {
+		t.Errorf("expected change lookup by address, got %v", rc)
+	}
+	if got := x.ByType("aws_kms_alias"); len(got) != 1 || got[0].Address != "aws_kms_alias.data" {
+		t.Errorf("expected one aws_kms_alias change, got %v", got)
+	}
+	if got := x.ByModule(""); len(got) != 4 {
+		t.Errorf("expected 4 root module changes, got %d", len(got))
+	}
+
+	if sr, ok := x.StateResource("module.db.aws_db_instance.orders"); !ok || sr.Type != "aws_db_instance" {
+		t.Error("expected prior state lookup to include child modules")
+	}
+	if got := x.StateByType("aws_sqs_queue"); len(got) != 1 {
+		t.Errorf("expected one aws_sqs_queue in prior state, got %d", len(got))
+	}
+	if got := len(x.StateResources()); got != 4 {
+		t.Errorf("expected 4 prior state resources, got %d", got)
+	}
+
+	refs := x.References("aws_sqs_queue.jobs", "kms_master_key_id")
+	if len(refs) != 2 || refs[0] != "aws_kms_key.data.key_id" {
+		t.Errorf("expected kms_master_key_id references, got %v", refs)
+	}
+	if refs := x.References(`module.db.aws_db_instance.orders["primary"]`, "kms_key_id"); len(refs) != 1 || refs[0] != "var.kms_key_arn" {
+		t.Errorf("expected module references with instance keys ignored, got %v", refs)
+	}
+	if refs := x.References("aws_sqs_queue.jobs", "name"); refs != nil {
+		t.Errorf("expected no references for a constant, got %v", refs)
+	}
+}
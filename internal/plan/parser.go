@@ -11,15 +11,77 @@ import (
 type Plan struct {
 	FormatVersion   string           `json:"format_version"`
 	ResourceChanges []ResourceChange `json:"resource_changes"`
+	// PriorState is the state the plan was made against, including
+	// resources the plan does not change. Nil for plans without state.
+	PriorState    *State         `json:"prior_state,omitempty"`
+	Configuration *Configuration `json:"configuration,omitempty"`
+}
+
+// State is a Terraform state snapshot as embedded in plan JSON.
+type State struct {
+	Values *StateValues `json:"values"`
+}
+
+// StateValues holds the root module of a state snapshot.
+type StateValues struct {
+	RootModule StateModule `json:"root_module"`
+}
+
+// StateModule is a module in a state snapshot. The root module has no
+// address.
+type StateModule struct {
+	Address      string          `json:"address"`
+	Resources    []StateResource `json:"resources"`
+	ChildModules []StateModule   `json:"child_modules"`
+}
+
+// StateResource is one resource instance in a state snapshot.
+type StateResource struct {
+	Address      string          `json:"address"`
+	Mode         string          `json:"mode"`
+	Type         string          `json:"type"`
+	Name         string          `json:"name"`
+	ProviderName string          `json:"provider_name"`
+	Values       json.RawMessage `json:"values"`
+}
+
+// Configuration is the parsed Terraform configuration embedded in plan JSON.
+type Configuration struct {
+	RootModule ConfigModule `json:"root_module"`
+}
+
+// ConfigModule is a module in the configuration.
+type ConfigModule struct {
+	Resources   []ConfigResource      `json:"resources"`
+	ModuleCalls map[string]ModuleCall `json:"module_calls"`
+}
+
+// ModuleCall is a module block and the module it loads.
+type ModuleCall struct {
+	Source string       `json:"source"`
+	Module ConfigModule `json:"module"`
+}
+
+// ConfigResource is a resource block in the configuration. Expressions map
+// attribute names to expression objects, which list the "references" of
+// non-constant values.
+type ConfigResource struct {
+	Address     string                     `json:"address"`
+	Mode        string                     `json:"mode"`
+	Type        string                     `json:"type"`
+	Name        string                     `json:"name"`
+	Expressions map[string]json.RawMessage `json:"expressions"`
 }
 
 // ResourceChange represents a single resource change in the plan.
 type ResourceChange struct {
-	Address      string `json:"address"`
-	Type         string `json:"type"`
-	Name         string `json:"name"`
-	ProviderName string `json:"provider_name"`
-	Change       Change `json:"change"`
+	Address       string `json:"address"`
+	ModuleAddress string `json:"module_address"`
+	Mode          string `json:"mode"` // "managed" or "data"
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	ProviderName  string `json:"provider_name"`
+	Change        Change `json:"change"`
 }
 
 // Change holds the before/after state and action list.
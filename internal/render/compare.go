@@ -0,0 +1,180 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/analysis"
+	"github.com/djeeteg007/tf-why/internal/compare"
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// CompareText renders a plan comparison as human-readable colored text.
+func CompareText(w io.Writer, r compare.Report) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %s\n", cb(brightWhite, "TERRAFORM PLAN COMPARISON"))
+	fmt.Fprintf(w, "  %s\n", c(dim, strings.Repeat("─", 50)))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %s  %s %s %s\n",
+		c(dim, "RISK"),
+		severityBadge(severityLabel(r.OldSeverity)),
+		c(dim, "→"),
+		severityBadge(severityLabel(r.NewSeverity)))
+
+	if r.Empty() {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "  %s  %s\n", c(brightGreen, "✓"), c(brightGreen, "No differences between the plans"))
+		fmt.Fprintln(w)
+		return
+	}
+
+	if len(r.AddedFindings) > 0 || len(r.RemovedFindings) > 0 || len(r.ChangedFindings) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "  %s\n", cb(white, "FINDINGS"))
+		for _, f := range r.AddedFindings {
+			fmt.Fprintf(w, "  %s %s  %s\n", cb(red, "+"), severityBadge(severityLabel(f.Severity)), f.Title)
+		}
+		for _, f := range r.RemovedFindings {
+			fmt.Fprintf(w, "  %s %s  %s\n", cb(green, "-"), severityBadge(severityLabel(f.Severity)), c(dim, f.Title))
+		}
+		for _, ch := range r.ChangedFindings {
+			fmt.Fprintf(w, "  %s %s  %s %s\n", cb(yellow, "~"), severityBadge(severityLabel(ch.New.Severity)), ch.New.Title,
+				c(dim, fmt.Sprintf("(was %s)", severityLabel(ch.Old.Severity))))
+		}
+	}
+
+	if len(r.Resources) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "  %s\n", cb(white, "RESOURCES"))
+		for _, d := range r.Resources {
+			fmt.Fprintf(w, "  %s  %s\n", cb(cyan, d.Address), c(dim, actionTransition(d)))
+			for _, a := range d.Attributes {
+				fmt.Fprintf(w, "  %s  %s\n", c(dim, "│"), a.String())
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// CompareJSON renders a plan comparison as machine-readable JSON.
+func CompareJSON(w io.Writer, r compare.Report) error {
+	type jsonChange struct {
+		OldSeverity string      `json:"old_severity"`
+		NewSeverity string      `json:"new_severity"`
+		Finding     jsonFinding `json:"finding"`
+	}
+	type jsonResource struct {
+		Address       string      `json:"address"`
+		Type          string      `json:"type"`
+		OldAction     string      `json:"old_action"`
+		NewAction     string      `json:"new_action"`
+		ActionChanged bool        `json:"action_changed"`
+		Attributes    []util.Diff `json:"attributes"`
+	}
+	out := struct {
+		OldSummary      analysis.Summary `json:"old_summary"`
+		NewSummary      analysis.Summary `json:"new_summary"`
+		OldSeverity     string           `json:"old_overall_severity"`
+		NewSeverity     string           `json:"new_overall_severity"`
+		AddedFindings   []jsonFinding    `json:"added_findings"`
+		RemovedFindings []jsonFinding    `json:"removed_findings"`
+		ChangedFindings []jsonChange     `json:"changed_findings"`
+		Resources       []jsonResource   `json:"resources"`
+	}{
+		OldSummary:      r.OldSummary,
+		NewSummary:      r.NewSummary,
+		OldSeverity:     r.OldSeverity.String(),
+		NewSeverity:     r.NewSeverity.String(),
+		AddedFindings:   toJSONFindings(r.AddedFindings),
+		RemovedFindings: toJSONFindings(r.RemovedFindings),
+		ChangedFindings: []jsonChange{},
+		Resources:       []jsonResource{},
+	}
+	for _, ch := range r.ChangedFindings {
+		out.ChangedFindings = append(out.ChangedFindings, jsonChange{
+			OldSeverity: ch.Old.Severity.String(),
+			NewSeverity: ch.New.Severity.String(),
+			Finding:     toJSONFindings([]analysis.Finding{ch.New})[0],
+		})
+	}
+	for _, d := range r.Resources {
+		attrs := d.Attributes
+		if attrs == nil {
+			attrs = []util.Diff{}
+		}
+		out.Resources = append(out.Resources, jsonResource{
+			Address:       d.Address,
+			Type:          d.Type,
+			OldAction:     d.OldAction,
+			NewAction:     d.NewAction,
+			ActionChanged: d.ActionChanged(),
+			Attributes:    attrs,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("encoding JSON output: %w", err)
+	}
+	return nil
+}
+
+// CompareMarkdown renders a plan comparison as GitHub-flavored markdown,
+// suitable for posting as a pull request comment.
+func CompareMarkdown(w io.Writer, r compare.Report) {
+	fmt.Fprintf(w, "## Terraform plan comparison\n\n")
+	fmt.Fprintf(w, "**Risk:** %s → %s\n\n", severityLabel(r.OldSeverity), severityLabel(r.NewSeverity))
+
+	if r.Empty() {
+		fmt.Fprintf(w, "No differences between the plans.\n")
+		return
+	}
+
+	if len(r.AddedFindings) > 0 || len(r.RemovedFindings) > 0 || len(r.ChangedFindings) > 0 {
+		fmt.Fprintf(w, "### Findings\n\n")
+		fmt.Fprintf(w, "| Change | Severity | Finding | Resource |\n")
+		fmt.Fprintf(w, "|--------|----------|---------|----------|\n")
+		for _, f := range r.AddedFindings {
+			fmt.Fprintf(w, "| added | %s | %s | `%s` |\n", severityLabel(f.Severity), mdEscape(f.Title), f.Address)
+		}
+		for _, f := range r.RemovedFindings {
+			fmt.Fprintf(w, "| removed | %s | %s | `%s` |\n", severityLabel(f.Severity), mdEscape(f.Title), f.Address)
+		}
+		for _, ch := range r.ChangedFindings {
+			fmt.Fprintf(w, "| severity changed | %s → %s | %s | `%s` |\n",
+				severityLabel(ch.Old.Severity), severityLabel(ch.New.Severity), mdEscape(ch.New.Title), ch.New.Address)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.Resources) > 0 {
+		fmt.Fprintf(w, "### Resources\n\n")
+		for _, d := range r.Resources {
+			fmt.Fprintf(w, "- `%s` — %s\n", d.Address, actionTransition(d))
+			for _, a := range d.Attributes {
+				fmt.Fprintf(w, "  - `%s`: `%s` → `%s`\n", a.Path, a.Before, a.After)
+			}
+		}
+	}
+}
+
+func actionTransition(d compare.ResourceDelta) string {
+	if !d.ActionChanged() {
+		return d.NewAction + " (planned values changed)"
+	}
+	return d.OldAction + " → " + d.NewAction
+}
+
+func severityLabel(s analysis.Severity) string {
+	if s == 0 {
+		return "NONE"
+	}
+	return strings.ToUpper(s.String())
+}
+
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -9,40 +9,45 @@ import (
 )
 
 type jsonOutput struct {
-	Summary         analysis.Summary   `json:"summary"`
-	OverallSeverity string             `json:"overall_severity"`
-	FindingsCount   int                `json:"findings_count"`
-	Findings        []jsonFinding      `json:"findings"`
+	Summary          analysis.Summary `json:"summary"`
+	OverallSeverity  string           `json:"overall_severity"`
+	RiskScore        int              `json:"risk_score"`
+	Profile          string           `json:"profile,omitempty"`
+	ProfileSource    string           `json:"profile_source,omitempty"`
+	FindingsCount    int              `json:"findings_count"`
+	Findings         []jsonFinding    `json:"findings"`
+	ExistingCount    int              `json:"existing_count,omitempty"`
+	ExistingFindings []jsonFinding    `json:"existing_findings,omitempty"`
 }
 
 type jsonFinding struct {
-	Severity        string   `json:"severity"`
-	Tags            []string `json:"tags"`
-	Title           string   `json:"title"`
-	Address         string   `json:"address"`
-	Why             []string `json:"why"`
-	Recommendations []string `json:"recommendations"`
+	Severity         string   `json:"severity"`
+	OriginalSeverity string   `json:"original_severity"`
+	Overridden       bool     `json:"overridden"`
+	Rule             string   `json:"rule"`
+	Fingerprint      string   `json:"fingerprint"`
+	Tags             []string `json:"tags"`
+	Title            string   `json:"title"`
+	Address          string   `json:"address"`
+	Why              []string `json:"why"`
+	Recommendations  []string `json:"recommendations"`
+
+	Score          int                    `json:"score"`
+	ScoreBreakdown []analysis.ScoreFactor `json:"score_breakdown"`
 }
 
 // JSON renders the analysis result as machine-readable JSON.
 func JSON(w io.Writer, result analysis.Result) error {
-	findings := make([]jsonFinding, len(result.Findings))
-	for i, f := range result.Findings {
-		findings[i] = jsonFinding{
-			Severity:        f.Severity.String(),
-			Tags:            f.Tags,
-			Title:           f.Title,
-			Address:         f.Address,
-			Why:             f.Why,
-			Recommendations: f.Recommendations,
-		}
-	}
-
 	out := jsonOutput{
-		Summary:         result.Summary,
-		OverallSeverity: result.OverallSeverity.String(),
-		FindingsCount:   len(result.Findings),
-		Findings:        findings,
+		Summary:          result.Summary,
+		OverallSeverity:  result.OverallSeverity.String(),
+		RiskScore:        result.Score,
+		Profile:          result.Profile,
+		ProfileSource:    result.ProfileSource,
+		FindingsCount:    len(result.Findings),
+		Findings:         toJSONFindings(result.Findings),
+		ExistingCount:    len(result.ExistingFindings),
+		ExistingFindings: toJSONFindings(result.ExistingFindings),
 	}
 
 	enc := json.NewEncoder(w)
@@ -52,3 +57,24 @@ func JSON(w io.Writer, result analysis.Result) error {
 	}
 	return nil
 }
+
+func toJSONFindings(in []analysis.Finding) []jsonFinding {
+	findings := make([]jsonFinding, len(in))
+	for i, f := range in {
+		findings[i] = jsonFinding{
+			Severity:         f.Severity.String(),
+			OriginalSeverity: f.OriginalSeverity.String(),
+			Overridden:       f.Overridden(),
+			Rule:             f.RuleID,
+			Fingerprint:      f.Fingerprint,
+			Tags:             f.Tags,
+			Title:            f.Title,
+			Address:          f.Address,
+			Why:              f.Why,
+			Recommendations:  f.Recommendations,
+			Score:            f.Score,
+			ScoreBreakdown:   f.ScoreBreakdown,
+		}
+	}
+	return findings
+}
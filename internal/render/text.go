@@ -17,6 +17,12 @@ func Text(w io.Writer, result analysis.Result) {
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "  %s\n", cb(brightWhite, "TERRAFORM PLAN ANALYSIS"))
 	fmt.Fprintf(w, "  %s\n", c(dim, strings.Repeat("─", 50)))
+	if result.Profile != "" {
+		fmt.Fprintf(w, "  %s  %s %s\n",
+			c(dim, "PROFILE"),
+			cb(cyan, result.Profile),
+			c(dim, fmt.Sprintf("(via %s)", result.ProfileSource)))
+	}
 
 	// Summary bar
 	fmt.Fprintln(w)
@@ -42,8 +48,13 @@ func Text(w io.Writer, result analysis.Result) {
 
 	if len(result.Findings) == 0 {
 		fmt.Fprintln(w)
-		fmt.Fprintf(w, "  %s  %s\n", c(brightGreen, "✓"), c(brightGreen, "No findings — plan looks safe"))
+		if len(result.ExistingFindings) > 0 {
+			fmt.Fprintf(w, "  %s  %s\n", c(brightGreen, "✓"), c(brightGreen, "No new findings — all findings are in the baseline"))
+		} else {
+			fmt.Fprintf(w, "  %s  %s\n", c(brightGreen, "✓"), c(brightGreen, "No findings — plan looks safe"))
+		}
 		fmt.Fprintln(w)
+		renderExisting(w, result.ExistingFindings)
 		return
 	}
 
@@ -52,13 +63,30 @@ func Text(w io.Writer, result analysis.Result) {
 	fmt.Fprintf(w, "  %s  %s %s\n",
 		c(dim, "RISK"),
 		severityBadge(sev),
-		c(dim, fmt.Sprintf("(%d finding%s)", len(result.Findings), plural(len(result.Findings)))))
+		c(dim, fmt.Sprintf("(%d finding%s, score %d)", len(result.Findings), plural(len(result.Findings)), result.Score)))
 	fmt.Fprintln(w)
 
 	// Findings
 	for i, f := range result.Findings {
 		renderFinding(w, i+1, f)
 	}
+
+	renderExisting(w, result.ExistingFindings)
+}
+
+// renderExisting lists baseline-matched findings compactly, one per line.
+func renderExisting(w io.Writer, findings []analysis.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  %s %s\n",
+		cb(white, "EXISTING FINDINGS"),
+		c(dim, fmt.Sprintf("(%d in baseline, not counted toward the threshold)", len(findings))))
+	fmt.Fprintf(w, "  %s\n", c(dim, strings.Repeat("─", 50)))
+	for _, f := range findings {
+		fmt.Fprintf(w, "  %s  %s\n", severityBadge(strings.ToUpper(f.Severity.String())), c(dim, f.Title))
+	}
+	fmt.Fprintln(w)
 }
 
 func renderFinding(w io.Writer, num int, f analysis.Finding) {
@@ -77,6 +105,16 @@ func renderFinding(w io.Writer, num int, f analysis.Finding) {
 		c(dim, "Resource:"),
 		cb(cyan, f.Address))
 
+	// Severity override (shown so the effective severity is auditable)
+	if f.Overridden() {
+		fmt.Fprintf(w, "  %s  %s %s %s %s\n",
+			c(dim, "│"),
+			c(dim, "Severity:"),
+			strings.ToUpper(f.OriginalSeverity.String()),
+			c(dim, "→"),
+			cb(sevCol, sev+" (override)"))
+	}
+
 	// Tags
 	if len(f.Tags) > 0 {
 		tagParts := make([]string, len(f.Tags))
@@ -89,6 +127,19 @@ func renderFinding(w io.Writer, num int, f analysis.Finding) {
 			strings.Join(tagParts, c(dim, ", ")))
 	}
 
+	// Score and how it was derived
+	if len(f.ScoreBreakdown) > 0 {
+		parts := make([]string, len(f.ScoreBreakdown))
+		for i, sf := range f.ScoreBreakdown {
+			parts[i] = sf.Factor + " " + sf.Effect
+		}
+		fmt.Fprintf(w, "  %s  %s %s %s\n",
+			c(dim, "│"),
+			c(dim, "Score:"),
+			cb(white, fmt.Sprintf("%d", f.Score)),
+			c(dim, "("+strings.Join(parts, ", ")+")"))
+	}
+
 	// Why
 	if len(f.Why) > 0 {
 		fmt.Fprintf(w, "  %s\n", c(dim, "│"))
@@ -0,0 +1,241 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+// AuroraTopologyRule detects Aurora changes that only show when a cluster's
+// instances are considered together: losing the writer or readers, resizing
+// every instance at once, shrinking serverless capacity and leaving a global
+// cluster. Instances are grouped by cluster_identifier.
+type AuroraTopologyRule struct{}
+
+func (r *AuroraTopologyRule) ID() string { return "aurora-topology" }
+
+// auroraInstance is one aws_rds_cluster_instance of a cluster.
+type auroraInstance struct {
+	Address       string
+	Action        plan.ActionKind
+	Before, After map[string]interface{}
+}
+
+// auroraCluster collects the resources of one cluster in the plan. Cluster
+// is nil when the aws_rds_cluster itself is not in the plan.
+type auroraCluster struct {
+	ID        string
+	Cluster   *plan.ResourceChange
+	Instances []auroraInstance
+}
+
+func (r *AuroraTopologyRule) EvaluatePlan(x *plan.Index) []RuleFinding {
+	clusters := make(map[string]*auroraCluster)
+	get := func(id string) *auroraCluster {
+		if clusters[id] == nil {
+			clusters[id] = &auroraCluster{ID: id}
+		}
+		return clusters[id]
+	}
+	clusterID := func(rc *plan.ResourceChange) string {
+		if rc.Mode == "data" {
+			return ""
+		}
+		if id := stringField(getAfterState(*rc), "cluster_identifier"); id != "" {
+			return id
+		}
+		return stringField(getBeforeState(*rc), "cluster_identifier")
+	}
+
+	for _, rc := range x.ByType("aws_rds_cluster") {
+		if id := clusterID(rc); id != "" {
+			get(id).Cluster = rc
+		}
+	}
+	for _, rc := range x.ByType("aws_rds_cluster_instance") {
+		id := clusterID(rc)
+		if id == "" {
+			continue
+		}
+		c := get(id)
+		c.Instances = append(c.Instances, auroraInstance{
+			Address: rc.Address,
+			Action:  rc.Change.Actions.ActionType(),
+			Before:  getBeforeState(*rc),
+			After:   getAfterState(*rc),
+		})
+	}
+
+	ids := make([]string, 0, len(clusters))
+	for id := range clusters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var findings []RuleFinding
+	for _, id := range ids {
+		findings = append(findings, clusters[id].evaluate()...)
+	}
+	return findings
+}
+
+// address returns where cluster-level findings are reported: the cluster
+// resource if the plan changes it, otherwise the first changed instance.
+func (c *auroraCluster) address() string {
+	if c.Cluster != nil && c.Cluster.Change.Actions.ActionType() != plan.ActionNoop {
+		return c.Cluster.Address
+	}
+	for _, in := range c.Instances {
+		if in.Action != plan.ActionNoop {
+			return in.Address
+		}
+	}
+	return c.Instances[0].Address
+}
+
+func (c *auroraCluster) evaluate() []RuleFinding {
+	var findings []RuleFinding
+	clusterAction := plan.ActionNoop
+	if c.Cluster != nil {
+		clusterAction = c.Cluster.Change.Actions.ActionType()
+	}
+	// Deleting the whole cluster is reported by the RDS and generic rules.
+	if clusterAction == plan.ActionDelete {
+		return nil
+	}
+
+	var before, after, removedWriters []string
+	var resized []string
+	for _, in := range c.Instances {
+		if in.Before != nil && in.Action != plan.ActionCreate {
+			before = append(before, in.Address)
+		}
+		if in.Action != plan.ActionDelete {
+			after = append(after, in.Address)
+		}
+		writer, _ := in.Before["writer"].(bool)
+		if writer && (in.Action == plan.ActionDelete || in.Action == plan.ActionReplace) {
+			removedWriters = append(removedWriters, in.Address)
+		}
+		prev, next := stringField(in.Before, "instance_class"), stringField(in.After, "instance_class")
+		if in.Action != plan.ActionDelete && prev != "" && next != "" && prev != next {
+			resized = append(resized, fmt.Sprintf("%s: %s → %s", in.Address, prev, next))
+		}
+	}
+
+	switch {
+	case len(before) > 0 && len(after) == 0:
+		findings = append(findings, RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"downtime"},
+			Title:    fmt.Sprintf("Aurora cluster %s loses all of its instances", c.ID),
+			Address:  c.address(),
+			Why: []string{
+				fmt.Sprintf("Instances: %d → 0", len(before)),
+				"The cluster keeps its storage but cannot serve reads or writes",
+			},
+			Recommendations: []string{
+				"Keep at least one aws_rds_cluster_instance, or delete the cluster deliberately",
+			},
+		})
+	case len(removedWriters) > 0:
+		findings = append(findings, RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"downtime"},
+			Title:    fmt.Sprintf("Aurora cluster %s writer instance is removed", c.ID),
+			Address:  removedWriters[0],
+			Why: []string{
+				fmt.Sprintf("%s is the current writer and will be destroyed", removedWriters[0]),
+				"Aurora fails over to a reader; writes are interrupted during the failover",
+			},
+			Recommendations: []string{
+				"Fail over to a reader first (set promotion_tier) so the writer is not the one removed",
+			},
+		})
+	}
+
+	if len(after) > 0 && len(after) < len(before) {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"capacity"},
+			Title:    fmt.Sprintf("Aurora cluster %s readers reduced from %d to %d", c.ID, len(before)-1, len(after)-1),
+			Address:  c.address(),
+			Why: []string{
+				fmt.Sprintf("Instances: %d → %d", len(before), len(after)),
+				"Less read capacity and fewer failover targets",
+			},
+			Recommendations: []string{
+				"Check reader load and keep a reader in another AZ for failover",
+			},
+		})
+	}
+
+	if len(resized) > 1 && len(resized) == len(after) {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"downtime", "capacity"},
+			Title:    fmt.Sprintf("All instances of Aurora cluster %s change instance_class at once", c.ID),
+			Address:  c.address(),
+			Why: append(resized,
+				"Terraform modifies the instances in parallel, so no instance keeps serving while the others restart",
+			),
+			Recommendations: []string{
+				"Resize readers first, fail over, then resize the old writer (one apply per step)",
+			},
+		})
+	}
+
+	if c.Cluster != nil {
+		findings = append(findings, c.checkCluster(clusterAction)...)
+	}
+	return findings
+}
+
+// checkCluster reports serverless capacity reductions and global cluster
+// membership removal on the cluster resource.
+func (c *auroraCluster) checkCluster(action plan.ActionKind) []RuleFinding {
+	before, after := getBeforeState(*c.Cluster), getAfterState(*c.Cluster)
+	var findings []RuleFinding
+
+	prevMax, ok1 := firstBlock(before, "serverlessv2_scaling_configuration")["max_capacity"].(float64)
+	nextMax, ok2 := firstBlock(after, "serverlessv2_scaling_configuration")["max_capacity"].(float64)
+	if ok1 && ok2 && nextMax < prevMax {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"capacity"},
+			Title:    fmt.Sprintf("Aurora Serverless v2 max capacity reduced on cluster %s", c.ID),
+			Address:  c.Cluster.Address,
+			Why: []string{
+				fmt.Sprintf("serverlessv2_scaling_configuration.max_capacity: %g → %g ACUs", prevMax, nextMax),
+				"Instances cannot scale past the new limit under peak load",
+			},
+			Recommendations: []string{
+				"Check peak ServerlessDatabaseCapacity in CloudWatch before lowering the limit",
+			},
+		})
+	}
+
+	global := stringField(before, "global_cluster_identifier")
+	if global != "" && (action == plan.ActionReplace || stringField(after, "global_cluster_identifier") == "") {
+		why := fmt.Sprintf("global_cluster_identifier: %q → %q", global, stringField(after, "global_cluster_identifier"))
+		if action == plan.ActionReplace {
+			why = "The cluster is replaced; the new cluster does not keep the old one's replication"
+		}
+		findings = append(findings, RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"downtime", "data"},
+			Title:    fmt.Sprintf("Aurora cluster %s leaves global cluster %s", c.ID, global),
+			Address:  c.Cluster.Address,
+			Why: []string{
+				why,
+				"The region stops receiving replicated data and is no longer a failover target",
+			},
+			Recommendations: []string{
+				"Confirm another secondary region covers disaster recovery",
+				"If this is the primary, fail over the global cluster first",
+			},
+		})
+	}
+	return findings
+}
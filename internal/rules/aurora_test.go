@@ -0,0 +1,34 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+func TestAuroraTopology(t *testing.T) {
+	p := loadTestPlan(t, "aurora_topology.json")
+	findings := (&AuroraTopologyRule{}).EvaluatePlan(plan.NewIndex(p))
+
+	want := []struct {
+		address string
+		title   string
+		sev     int
+	}{
+		{"aws_rds_cluster_instance.audit", "Aurora cluster audit loses all of its instances", SeverityHigh},
+		{"aws_rds_cluster_instance.orders[0]", "Aurora cluster orders writer instance is removed", SeverityMedium},
+		{"aws_rds_cluster.orders", "Aurora cluster orders readers reduced from 2 to 0", SeverityMedium},
+		{"aws_rds_cluster.orders", "Aurora Serverless v2 max capacity reduced on cluster orders", SeverityMedium},
+		{"aws_rds_cluster.orders", "Aurora cluster orders leaves global cluster orders-global", SeverityHigh},
+		{"aws_rds_cluster_instance.reports[0]", "All instances of Aurora cluster reports change instance_class at once", SeverityHigh},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %v", len(want), len(findings), findings)
+	}
+	for i, w := range want {
+		f := findings[i]
+		if f.Address != w.address || f.Title != w.title || f.Severity != w.sev {
+			t.Errorf("finding %d: expected %s %s %q, got %s %s %q", i, severityName(w.sev), w.address, w.title, severityName(f.Severity), f.Address, f.Title)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// ComputeRule detects EC2 and autoscaling changes that reduce capacity or
+// restart instances: Auto Scaling group size reductions, instance refreshes
+// that replace every instance at once, instance type and user_data changes,
+// and Application Auto Scaling target reductions.
+type ComputeRule struct{}
+
+func (r *ComputeRule) ID() string { return "compute" }
+
+func (r *ComputeRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
+	if rc.Mode == "data" {
+		return nil
+	}
+	action := rc.Change.Actions.ActionType()
+	if action != plan.ActionUpdate && action != plan.ActionReplace {
+		return nil
+	}
+	before, after := getBeforeState(rc), getAfterState(rc)
+	if before == nil || after == nil {
+		return nil
+	}
+
+	switch rc.Type {
+	case "aws_autoscaling_group":
+		if action != plan.ActionUpdate {
+			return nil
+		}
+		return checkAutoscalingGroup(rc, before, after)
+	case "aws_instance":
+		return checkInstance(rc, action, before, after)
+	case "aws_appautoscaling_target":
+		if action != plan.ActionUpdate {
+			return nil
+		}
+		return checkAppAutoscalingTarget(rc, before, after)
+	}
+	return nil
+}
+
+// sizeField returns an integer attribute, and false when it is absent or
+// unknown.
+func sizeField(data map[string]interface{}, key string) (int, bool) {
+	v, ok := data[key].(float64)
+	return int(v), ok
+}
+
+func checkAutoscalingGroup(rc plan.ResourceChange, before, after map[string]interface{}) []RuleFinding {
+	var findings []RuleFinding
+
+	var why []string
+	toZero := false
+	for _, key := range []string{"min_size", "desired_capacity"} {
+		prev, ok1 := sizeField(before, key)
+		next, ok2 := sizeField(after, key)
+		if ok1 && ok2 && next < prev {
+			why = append(why, fmt.Sprintf("%s: %d → %d", key, prev, next))
+			toZero = toZero || (key == "desired_capacity" && next == 0)
+		}
+	}
+	desired, ok1 := sizeField(before, "desired_capacity")
+	prevMax, _ := sizeField(before, "max_size")
+	nextMax, ok2 := sizeField(after, "max_size")
+	if ok1 && ok2 && nextMax < desired && nextMax != prevMax {
+		why = append(why, fmt.Sprintf("max_size: %d → %d, below the current desired_capacity of %d", prevMax, nextMax, desired))
+		toZero = toZero || nextMax == 0
+	}
+	if len(why) > 0 {
+		f := RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"capacity"},
+			Title:    fmt.Sprintf("Auto Scaling group %s capacity reduced", rc.Address),
+			Address:  rc.Address,
+			Why:      append(why, "Instances above the new limits are terminated"),
+			Recommendations: []string{
+				"Verify the remaining instances can handle current load",
+				"If scaling policies manage desired_capacity, leave it out of the configuration",
+			},
+		}
+		if toZero {
+			f.Severity = SeverityHigh
+			f.Tags = []string{"downtime", "capacity"}
+			f.Title = fmt.Sprintf("Auto Scaling group %s scaled to zero", rc.Address)
+		}
+		findings = append(findings, f)
+	}
+
+	if f, ok := instanceRefreshFinding(rc, before, after); ok {
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// launchTemplateRef describes the launch template of an Auto Scaling group,
+// from launch_template or mixed_instances_policy.
+func launchTemplateRef(data map[string]interface{}) string {
+	lt := firstBlock(data, "launch_template")
+	if lt == nil {
+		policy := firstBlock(data, "mixed_instances_policy")
+		lt = firstBlock(firstBlock(policy, "launch_template"), "launch_template_specification")
+	}
+	if lt == nil {
+		return ""
+	}
+	var name string
+	for _, key := range []string{"name", "launch_template_name", "id", "launch_template_id"} {
+		if name = stringField(lt, key); name != "" {
+			break
+		}
+	}
+	return fmt.Sprintf("%s version %s", name, stringField(lt, "version"))
+}
+
+// instanceRefreshFinding reports a launch template change that starts an
+// instance refresh allowing less than half of the group to stay healthy.
+func instanceRefreshFinding(rc plan.ResourceChange, before, after map[string]interface{}) (RuleFinding, bool) {
+	refresh := firstBlock(after, "instance_refresh")
+	prevLT, nextLT := launchTemplateRef(before), launchTemplateRef(after)
+	if refresh == nil || prevLT == nextLT {
+		return RuleFinding{}, false
+	}
+	prefs := firstBlock(refresh, "preferences")
+	minHealthy, ok := sizeField(prefs, "min_healthy_percentage")
+	if !ok {
+		minHealthy = 90 // AWS default
+	}
+	if minHealthy >= 50 {
+		return RuleFinding{}, false
+	}
+
+	f := RuleFinding{
+		Severity: SeverityMedium,
+		Tags:     []string{"downtime", "capacity"},
+		Title:    fmt.Sprintf("Instance refresh replaces most of Auto Scaling group %s at once", rc.Address),
+		Address:  rc.Address,
+		Why: []string{
+			fmt.Sprintf("launch template: %s → %s", prevLT, nextLT),
+			fmt.Sprintf("instance_refresh (strategy %s) starts with preferences.min_healthy_percentage = %d", stringField(refresh, "strategy"), minHealthy),
+		},
+		Recommendations: []string{
+			"Keep min_healthy_percentage at 90 or more, or use max_healthy_percentage above 100 to launch before terminating",
+			"Add checkpoints (checkpoint_percentages) to pause the refresh and verify the new instances",
+		},
+	}
+	if minHealthy == 0 {
+		f.Severity = SeverityHigh
+		f.Title = fmt.Sprintf("Instance refresh replaces every instance of Auto Scaling group %s at once", rc.Address)
+		f.Why = append(f.Why, "All instances can be terminated before their replacements are healthy")
+	}
+	return f, true
+}
+
+func checkInstance(rc plan.ResourceChange, action plan.ActionKind, before, after map[string]interface{}) []RuleFinding {
+	var userData []string
+	for _, key := range []string{"user_data", "user_data_base64"} {
+		if prev, next := stringField(before, key), stringField(after, key); prev != next {
+			userData = append(userData, fmt.Sprintf("%s changed", key))
+		}
+	}
+
+	if action == plan.ActionReplace {
+		var why []string
+		for _, rp := range util.ExtractReplacePaths(rc.Change.ReplacePaths) {
+			why = append(why, fmt.Sprintf("replace triggered by: %s", rp))
+		}
+		title := fmt.Sprintf("EC2 instance %s will be replaced", rc.Address)
+		if replaceOnChange, _ := after["user_data_replace_on_change"].(bool); replaceOnChange && len(userData) > 0 {
+			title = fmt.Sprintf("EC2 instance %s will be replaced because user_data changed", rc.Address)
+			why = append(why, strings.Join(userData, ", ")+" with user_data_replace_on_change = true")
+		}
+		why = append(why, "The instance is terminated before its replacement starts: its private IP, instance store and any root volume with delete_on_termination are lost")
+		return []RuleFinding{{
+			Severity: SeverityHigh,
+			Tags:     []string{"downtime"},
+			Title:    title,
+			Address:  rc.Address,
+			Why:      why,
+			Recommendations: []string{
+				"Run the instance behind an Auto Scaling group or load balancer so one replacement is not an outage",
+				"Use create_before_destroy, or apply during a maintenance window",
+			},
+		}}
+	}
+
+	var why []string
+	prevType, nextType := stringField(before, "instance_type"), stringField(after, "instance_type")
+	if prevType != "" && nextType != "" && prevType != nextType {
+		why = append(why, fmt.Sprintf("instance_type: %s → %s", prevType, nextType))
+	}
+	why = append(why, userData...)
+	if len(why) == 0 {
+		return nil
+	}
+	return []RuleFinding{{
+		Severity: SeverityMedium,
+		Tags:     []string{"downtime"},
+		Title:    fmt.Sprintf("EC2 instance %s is stopped and started to apply changes", rc.Address),
+		Address:  rc.Address,
+		Why: append(why,
+			"The instance is unavailable while it restarts; its public IPv4 address changes unless it has an Elastic IP, and instance store data is lost",
+		),
+		Recommendations: []string{
+			"Apply during a maintenance window, or move traffic away from the instance first",
+			"user_data scripts run only at first boot unless cloud-init is configured to run them on every boot",
+		},
+	}}
+}
+
+func checkAppAutoscalingTarget(rc plan.ResourceChange, before, after map[string]interface{}) []RuleFinding {
+	var why []string
+	toZero := false
+	for _, key := range []string{"min_capacity", "max_capacity"} {
+		prev, ok1 := sizeField(before, key)
+		next, ok2 := sizeField(after, key)
+		if ok1 && ok2 && next < prev {
+			why = append(why, fmt.Sprintf("%s: %d → %d", key, prev, next))
+			toZero = toZero || (key == "max_capacity" && next == 0)
+		}
+	}
+	if len(why) == 0 {
+		return nil
+	}
+	resource := fmt.Sprintf("%s (%s)", stringField(after, "resource_id"), stringField(after, "scalable_dimension"))
+	f := RuleFinding{
+		Severity: SeverityMedium,
+		Tags:     []string{"capacity"},
+		Title:    fmt.Sprintf("Application Auto Scaling target %s capacity reduced", rc.Address),
+		Address:  rc.Address,
+		Why:      append([]string{"Scalable target: " + resource}, why...),
+		Recommendations: []string{
+			"Check peak utilization stays within the new maximum",
+		},
+	}
+	if toZero {
+		f.Severity = SeverityHigh
+		f.Tags = []string{"downtime", "capacity"}
+		f.Title = fmt.Sprintf("Application Auto Scaling target %s scaled to zero", rc.Address)
+	}
+	return []RuleFinding{f}
+}
@@ -0,0 +1,58 @@
+package rules
+
+import "testing"
+
+func TestComputeASGRefresh(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_autoscaling_group.web"),
+		wantFinding{"Auto Scaling group aws_autoscaling_group.web capacity reduced", SeverityMedium, "desired_capacity: 6 → 3"},
+		wantFinding{"replaces every instance", SeverityHigh, "launch template: web version 3 → web version 4"})
+}
+
+func TestComputeASGMaxBelowDesired(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_autoscaling_group.batch"),
+		wantFinding{"capacity reduced", SeverityMedium, "max_size: 8 → 2, below the current desired_capacity of 4"})
+}
+
+func TestComputeASGScaledToZero(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_autoscaling_group.workers"),
+		wantFinding{"scaled to zero", SeverityHigh, "desired_capacity: 2 → 0"},
+		wantFinding{"replaces most of", SeverityMedium, "min_healthy_percentage = 40"})
+}
+
+func TestComputeASGDefaultMinHealthy(t *testing.T) {
+	// The default min_healthy_percentage keeps 90% of the group in service.
+	if findings := evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_autoscaling_group.api"); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestComputeInstanceTypeChange(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_instance.bastion"),
+		wantFinding{"is stopped and started", SeverityMedium, "instance_type: t3.micro → t3.small"})
+}
+
+func TestComputeUserDataReplace(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_instance.app"),
+		wantFinding{"will be replaced because user_data changed", SeverityHigh, "user_data changed with user_data_replace_on_change = true"})
+}
+
+func TestComputeScalableTargetReduced(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_appautoscaling_target.api"),
+		wantFinding{"capacity reduced", SeverityMedium, "max_capacity: 10 → 4"})
+}
+
+func TestComputeScalableTargetScaledToZero(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ComputeRule{}, "compute_capacity.json", "aws_appautoscaling_target.reader"),
+		wantFinding{"scaled to zero", SeverityHigh, "Scalable target: service/main/reader (ecs:service:DesiredCount)"})
+}
+
+func TestComputeFindingTags(t *testing.T) {
+	p := loadTestPlan(t, "compute_capacity.json")
+	for _, rc := range p.ResourceChanges {
+		for _, f := range (&ComputeRule{}).Evaluate(rc) {
+			if !containsTag(f.Tags, "capacity") && !containsTag(f.Tags, "downtime") {
+				t.Errorf("%s: expected capacity or downtime tag, got %v", f.Title, f.Tags)
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package rules
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// DynamoDBRule detects DynamoDB table changes that lose data or break
+// readers: replacement, disabled deletion protection and point-in-time
+// recovery, stream changes, removed global secondary indexes, billing mode
+// switches and removed global table replicas.
+type DynamoDBRule struct{}
+
+func (r *DynamoDBRule) ID() string { return "dynamodb" }
+
+func (r *DynamoDBRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
+	if rc.Mode == "data" {
+		return nil
+	}
+	action := rc.Change.Actions.ActionType()
+	switch rc.Type {
+	case "aws_dynamodb_table":
+		if action != plan.ActionUpdate && action != plan.ActionReplace {
+			return nil
+		}
+	case "aws_dynamodb_table_replica":
+		if action != plan.ActionDelete && action != plan.ActionReplace {
+			return nil
+		}
+		return []RuleFinding{dynamoReplicaFinding(rc.Address, []string{
+			fmt.Sprintf("The replica of %s is %sd with its data", stringField(getBeforeState(rc), "global_table_arn"), action),
+		})}
+	default:
+		return nil
+	}
+
+	before, after := getBeforeState(rc), getAfterState(rc)
+	if before == nil || after == nil {
+		return nil
+	}
+
+	var findings []RuleFinding
+	finding := func(sev int, tags []string, title string, why []string, recs ...string) {
+		findings = append(findings, RuleFinding{
+			Severity:        sev,
+			Tags:            tags,
+			Title:           fmt.Sprintf(title, rc.Address),
+			Address:         rc.Address,
+			Why:             why,
+			Recommendations: recs,
+		})
+	}
+
+	if action == plan.ActionReplace {
+		why := dynamoReplaceReasons(rc, before, after)
+		if on, _ := before["deletion_protection_enabled"].(bool); on {
+			why = append(why, "deletion_protection_enabled = true in the current state: the destroy fails unless it is turned off first")
+		}
+		finding(SeverityHigh, []string{"data", "downtime"}, "DynamoDB table %s will be replaced — all items are deleted",
+			append(why, "The table is destroyed and recreated empty; items, backups and streams are not carried over"),
+			"Export the table (or restore from a point-in-time backup) into a new table and switch readers over",
+			"If the key schema must change, create the new table alongside the old one and migrate the data")
+		return findings
+	}
+
+	if changedBool(before, after, "deletion_protection_enabled", true, false) {
+		finding(SeverityMedium, []string{"data"}, "Deletion protection disabled on DynamoDB table %s",
+			[]string{"deletion_protection_enabled: true → false"},
+			"Keep deletion protection on tables holding production data")
+	}
+
+	prevPITR, _ := firstBlock(before, "point_in_time_recovery")["enabled"].(bool)
+	nextPITR, _ := firstBlock(after, "point_in_time_recovery")["enabled"].(bool)
+	if prevPITR && !nextPITR {
+		finding(SeverityHigh, []string{"data"}, "Point-in-time recovery disabled on DynamoDB table %s",
+			[]string{
+				"point_in_time_recovery.enabled: true → false",
+				"Existing continuous backups are discarded; the table can no longer be restored to a point in time",
+			},
+			"Keep point_in_time_recovery enabled, or take an on-demand backup first")
+	}
+
+	if why := dynamoStreamChanges(before, after); len(why) > 0 {
+		finding(SeverityMedium, []string{"ops"}, "DynamoDB stream changes on %s",
+			append(why, "Consumers of the current stream (Lambda triggers, Kinesis adapters, replicas) stop receiving records; a new stream gets a new ARN"),
+			"Update event source mappings and other consumers to the new stream ARN",
+			"Check consumers handle the new record format if stream_view_type changes")
+	}
+
+	if removed := removedNames(before, after, "global_secondary_index", "name"); len(removed) > 0 {
+		var why []string
+		for _, name := range removed {
+			why = append(why, fmt.Sprintf("global_secondary_index %q is removed", name))
+		}
+		finding(SeverityMedium, []string{"ops"}, "DynamoDB global secondary index removed on %s",
+			append(why, "Queries against the index fail once it is deleted"),
+			"Confirm no application queries the index before removing it")
+	}
+
+	prevMode, nextMode := dynamoBillingMode(before), dynamoBillingMode(after)
+	if prevMode != nextMode {
+		why := []string{fmt.Sprintf("billing_mode: %s → %s", prevMode, nextMode)}
+		if nextMode == "PROVISIONED" {
+			why = append(why, fmt.Sprintf("Capacity is capped at read_capacity = %d, write_capacity = %d; traffic above that is throttled",
+				intFromJSON(after["read_capacity"]), intFromJSON(after["write_capacity"])))
+		} else {
+			why = append(why, "On-demand pricing applies per request; steady high traffic may cost more")
+		}
+		why = append(why, "Billing mode can be switched only once every 24 hours")
+		finding(SeverityMedium, []string{"ops", "capacity"}, "DynamoDB billing mode changes on %s", why,
+			"Compare consumed capacity with the new limits or costs before switching")
+	}
+
+	if removed := removedNames(before, after, "replica", "region_name"); len(removed) > 0 {
+		var why []string
+		for _, region := range removed {
+			why = append(why, fmt.Sprintf("replica in %s is removed", region))
+		}
+		why = append(why, "The replica table in each region is deleted with its data; readers and writers there lose the table")
+		findings = append(findings, dynamoReplicaFinding(rc.Address, why))
+	}
+	return findings
+}
+
+// dynamoReplaceReasons explains a table replacement: replace paths, and the
+// key schema, attribute and local index changes that usually force it.
+func dynamoReplaceReasons(rc plan.ResourceChange, before, after map[string]interface{}) []string {
+	var why []string
+	for _, rp := range util.ExtractReplacePaths(rc.Change.ReplacePaths) {
+		why = append(why, fmt.Sprintf("replace triggered by: %s", rp))
+	}
+	for _, key := range []string{"name", "hash_key", "range_key"} {
+		if prev, next := stringField(before, key), stringField(after, key); prev != next {
+			why = append(why, fmt.Sprintf("%s: %q → %q", key, prev, next))
+		}
+	}
+	if prev, next := dynamoAttributes(before), dynamoAttributes(after); prev != next {
+		why = append(why, fmt.Sprintf("attribute: [%s] → [%s]", prev, next))
+	}
+	if !reflect.DeepEqual(before["local_secondary_index"], after["local_secondary_index"]) {
+		why = append(why, fmt.Sprintf("local_secondary_index: [%s] → [%s]",
+			strings.Join(blockNames(before, "local_secondary_index", "name"), ", "),
+			strings.Join(blockNames(after, "local_secondary_index", "name"), ", ")))
+	}
+	return why
+}
+
+// dynamoAttributes describes attribute definitions, e.g. "pk (S), sk (N)".
+func dynamoAttributes(data map[string]interface{}) string {
+	list, _ := data["attribute"].([]interface{})
+	var parts []string
+	for _, item := range list {
+		attr, _ := item.(map[string]interface{})
+		parts = append(parts, fmt.Sprintf("%s (%s)", stringField(attr, "name"), stringField(attr, "type")))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func dynamoStreamChanges(before, after map[string]interface{}) []string {
+	prevOn, _ := before["stream_enabled"].(bool)
+	nextOn, _ := after["stream_enabled"].(bool)
+	prevView, nextView := stringField(before, "stream_view_type"), stringField(after, "stream_view_type")
+	switch {
+	case !prevOn:
+		return nil
+	case !nextOn:
+		return []string{"stream_enabled: true → false"}
+	case prevView != nextView:
+		return []string{fmt.Sprintf("stream_view_type: %s → %s", prevView, nextView)}
+	}
+	return nil
+}
+
+// dynamoBillingMode returns billing_mode, which defaults to PROVISIONED.
+func dynamoBillingMode(data map[string]interface{}) string {
+	if mode := stringField(data, "billing_mode"); mode != "" {
+		return mode
+	}
+	return "PROVISIONED"
+}
+
+func dynamoReplicaFinding(address string, why []string) RuleFinding {
+	return RuleFinding{
+		Severity: SeverityHigh,
+		Tags:     []string{"data"},
+		Title:    fmt.Sprintf("DynamoDB global table replica removed on %s", address),
+		Address:  address,
+		Why:      why,
+		Recommendations: []string{
+			"Move traffic in the replica's region to another region first",
+			"Removing a replica cannot be undone without re-adding it and waiting for it to resync",
+		},
+	}
+}
+
+// blockNames returns the values of field in the blocks of data[key].
+func blockNames(data map[string]interface{}, key, field string) []string {
+	list, _ := data[key].([]interface{})
+	var names []string
+	for _, item := range list {
+		block, _ := item.(map[string]interface{})
+		if name := stringField(block, field); name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// removedNames returns the names of blocks in before[key] that are not in
+// after[key], matched by field.
+func removedNames(before, after map[string]interface{}, key, field string) []string {
+	kept := make(map[string]bool)
+	for _, name := range blockNames(after, key, field) {
+		kept[name] = true
+	}
+	var removed []string
+	for _, name := range blockNames(before, key, field) {
+		if !kept[name] {
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
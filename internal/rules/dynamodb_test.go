@@ -0,0 +1,65 @@
+package rules
+
+import "testing"
+
+func TestDynamoDBReplace(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &DynamoDBRule{}, "dynamodb_protection.json", "aws_dynamodb_table.orders"),
+		wantFinding{"will be replaced — all items are deleted", SeverityHigh, `hash_key: "pk" → "order_id"`})
+}
+
+func TestDynamoDBReplaceReportedOnce(t *testing.T) {
+	// The replacement is reported once, by this rule, with the data tag.
+	findings := evaluateAll(t, "dynamodb_protection.json")
+	replaced := 0
+	for _, f := range findings {
+		if f.Address == "aws_dynamodb_table.orders" {
+			replaced++
+			if !containsTag(f.Tags, "data") {
+				t.Errorf("expected data tag on replacement, got %v", f.Tags)
+			}
+			if !anyContains(f.Why, "the destroy fails unless it is turned off first") {
+				t.Errorf("expected deletion protection note, got %v", f.Why)
+			}
+		}
+	}
+	if replaced != 1 {
+		t.Errorf("expected 1 finding for the replacement, got %d", replaced)
+	}
+}
+
+func TestDynamoDBProtectionRemoved(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &DynamoDBRule{}, "dynamodb_protection.json", "aws_dynamodb_table.sessions"),
+		wantFinding{"Deletion protection disabled", SeverityMedium, ""},
+		wantFinding{"Point-in-time recovery disabled", SeverityHigh, ""},
+		wantFinding{"DynamoDB stream changes", SeverityMedium, "stream_view_type: NEW_AND_OLD_IMAGES → KEYS_ONLY"},
+		wantFinding{"global secondary index removed", SeverityMedium, `global_secondary_index "by_user" is removed`},
+		wantFinding{"billing mode changes", SeverityMedium, "read_capacity = 5, write_capacity = 5"})
+}
+
+func TestDynamoDBStreamDisabledAndReplicaRemoved(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &DynamoDBRule{}, "dynamodb_protection.json", "aws_dynamodb_table.events"),
+		wantFinding{"DynamoDB stream changes", SeverityMedium, "stream_enabled: true → false"},
+		wantFinding{"global table replica removed", SeverityHigh, "replica in eu-west-1 is removed"})
+}
+
+func TestDynamoDBProtectionAlreadyOff(t *testing.T) {
+	// Protections that were never enabled are not a regression.
+	if findings := evaluateAddress(t, &DynamoDBRule{}, "dynamodb_protection.json", "aws_dynamodb_table.cache"); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestDynamoDBReplicaResourceDelete(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &DynamoDBRule{}, "dynamodb_protection.json", "aws_dynamodb_table_replica.audit_eu"),
+		wantFinding{"global table replica removed", SeverityHigh, "table/audit is deleted"})
+}
+
+func TestDynamoDBReplicaResourceReplaceReportedOnce(t *testing.T) {
+	var replaced []RuleFinding
+	for _, f := range evaluateAll(t, "dynamodb_protection.json") {
+		if f.Address == "aws_dynamodb_table_replica.audit_ap" {
+			replaced = append(replaced, f)
+		}
+	}
+	checkFindings(t, replaced, wantFinding{"global table replica removed", SeverityHigh, "table/audit is replaced"})
+}
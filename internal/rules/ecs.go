@@ -3,20 +3,32 @@ package rules
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/util"
 )
 
-// ECSRule detects risky ECS service changes.
+// ECSRule detects risky ECS service and task definition changes.
 type ECSRule struct{}
 
+func (r *ECSRule) ID() string { return "ecs" }
+
 func (r *ECSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
-	if rc.Type != "aws_ecs_service" {
-		return nil
+	switch rc.Type {
+	case "aws_ecs_service":
+		return r.checkService(rc)
+	case "aws_ecs_task_definition":
+		return checkTaskDefinition(rc)
 	}
+	return nil
+}
 
+func (r *ECSRule) checkService(rc plan.ResourceChange) []RuleFinding {
 	action := rc.Change.Actions.ActionType()
-	if action != plan.ActionUpdate {
+	if action != plan.ActionUpdate && action != plan.ActionReplace {
 		return nil
 	}
 
@@ -34,10 +46,29 @@ func (r *ECSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 
 	var findings []RuleFinding
 
+	if action == plan.ActionReplace {
+		findings = append(findings, serviceReplaceFinding(rc, beforeMap, afterMap))
+	}
+
 	// Check desired_count decrease.
 	beforeCount := intFromJSONInterface(beforeMap["desired_count"])
 	afterCount := intFromJSONInterface(afterMap["desired_count"])
-	if beforeCount > 0 && afterCount >= 0 && afterCount < beforeCount {
+	if beforeCount > 0 && afterCount == 0 {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"downtime", "capacity"},
+			Title:    fmt.Sprintf("ECS service %s scaled to zero", rc.Address),
+			Address:  rc.Address,
+			Why: []string{
+				fmt.Sprintf("desired_count: %d → 0", beforeCount),
+				"All running tasks are stopped",
+			},
+			Recommendations: []string{
+				"Confirm the service is meant to stop serving traffic",
+				"If capacity is managed by autoscaling, set the minimum there instead",
+			},
+		})
+	} else if beforeCount > 0 && afterCount >= 0 && afterCount < beforeCount {
 		findings = append(findings, RuleFinding{
 			Severity: SeverityMedium,
 			Tags:     []string{"ops", "capacity"},
@@ -72,9 +103,124 @@ func (r *ECSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 		})
 	}
 
+	// Check deployment circuit breaker removal.
+	breaker := func(data map[string]interface{}) bool {
+		enabled, _ := firstBlock(data, "deployment_circuit_breaker")["enable"].(bool)
+		return enabled
+	}
+	if breaker(beforeMap) && !breaker(afterMap) {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"ops"},
+			Title:    fmt.Sprintf("ECS deployment circuit breaker removed on %s", rc.Address),
+			Address:  rc.Address,
+			Why: []string{
+				"deployment_circuit_breaker.enable: true → false (or block removed)",
+				"Failing deployments keep replacing tasks instead of stopping and rolling back",
+			},
+			Recommendations: []string{
+				"Keep deployment_circuit_breaker { enable = true, rollback = true }",
+			},
+		})
+	}
+
+	// Check launch type and capacity provider changes, in place.
+	if action == plan.ActionUpdate {
+		if why := serviceComputeChanges(beforeMap, afterMap); len(why) > 0 {
+			findings = append(findings, RuleFinding{
+				Severity: SeverityMedium,
+				Tags:     []string{"ops", "capacity"},
+				Title:    fmt.Sprintf("ECS service compute changes on %s", rc.Address),
+				Address:  rc.Address,
+				Why:      append(why, "Tasks are redeployed onto the new capacity"),
+				Recommendations: []string{
+					"Confirm the new capacity providers have enough capacity before applying",
+				},
+			})
+		}
+	}
+
 	return findings
 }
 
+// serviceReplaceFinding explains an ECS service replacement. Replacing a
+// service deletes it first, stopping all tasks and deregistering them from
+// their target groups.
+func serviceReplaceFinding(rc plan.ResourceChange, before, after map[string]interface{}) RuleFinding {
+	var why []string
+	for _, rp := range util.ExtractReplacePaths(rc.Change.ReplacePaths) {
+		why = append(why, fmt.Sprintf("replace triggered by: %s", rp))
+	}
+	if !reflect.DeepEqual(before["load_balancer"], after["load_balancer"]) {
+		why = append(why, fmt.Sprintf("load_balancer: %s → %s", describeLoadBalancers(before), describeLoadBalancers(after)))
+	}
+	why = append(why, serviceComputeChanges(before, after)...)
+	why = append(why, "The service is deleted before it is recreated: all tasks stop and are deregistered from their target groups")
+
+	return RuleFinding{
+		Severity: SeverityHigh,
+		Tags:     []string{"downtime"},
+		Title:    fmt.Sprintf("ECS service %s will be replaced", rc.Address),
+		Address:  rc.Address,
+		Why:      why,
+		Recommendations: []string{
+			"Create the new service alongside the old one (new name), shift traffic, then remove the old one",
+			"Or apply during a maintenance window",
+		},
+	}
+}
+
+func serviceComputeChanges(before, after map[string]interface{}) []string {
+	var why []string
+	prev, next := stringField(before, "launch_type"), stringField(after, "launch_type")
+	if prev != next {
+		why = append(why, fmt.Sprintf("launch_type: %q → %q", prev, next))
+	}
+	prevCP, nextCP := capacityProviders(before), capacityProviders(after)
+	if prevCP != nextCP {
+		why = append(why, fmt.Sprintf("capacity_provider_strategy: [%s] → [%s]", prevCP, nextCP))
+	}
+	return why
+}
+
+// capacityProviders describes a capacity provider strategy, e.g.
+// "FARGATE base=1 weight=1, FARGATE_SPOT weight=3".
+func capacityProviders(data map[string]interface{}) string {
+	list, _ := data["capacity_provider_strategy"].([]interface{})
+	var parts []string
+	for _, item := range list {
+		cp, _ := item.(map[string]interface{})
+		part := stringField(cp, "capacity_provider")
+		if base := intFromJSON(cp["base"]); base > 0 {
+			part += fmt.Sprintf(" base=%d", base)
+		}
+		part += fmt.Sprintf(" weight=%d", intFromJSON(cp["weight"]))
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func describeLoadBalancers(data map[string]interface{}) string {
+	list, _ := data["load_balancer"].([]interface{})
+	if len(list) == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, item := range list {
+		lb, _ := item.(map[string]interface{})
+		target := stringField(lb, "target_group_arn")
+		if target == "" {
+			target = stringField(lb, "elb_name")
+		}
+		if i := strings.LastIndex(target, ":"); i >= 0 {
+			target = target[i+1:]
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d (%s)", stringField(lb, "container_name"), intFromJSON(lb["container_port"]), target))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
 func getDeploymentConfigValue(data map[string]interface{}, key string) int {
 	// The field can be at top level or nested under deployment_configuration.
 	if val, ok := data[key]; ok {
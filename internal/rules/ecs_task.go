@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+// containerDefinition holds the fields of an ECS container definition that
+// the rule checks. container_definitions is a JSON string in the plan.
+type containerDefinition struct {
+	Name              string         `json:"name"`
+	Image             string         `json:"image"`
+	CPU               int            `json:"cpu"`
+	Memory            int            `json:"memory"`
+	MemoryReservation int            `json:"memoryReservation"`
+	Environment       []containerEnv `json:"environment"`
+	Secrets           []containerEnv `json:"secrets"`
+}
+
+type containerEnv struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	ValueFrom string `json:"valueFrom"`
+}
+
+// secretEnvName matches environment variable names that usually hold
+// credentials.
+var secretEnvName = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|ACCESS_?KEY|CREDENTIAL)`)
+
+// parseContainerDefinitions decodes the container_definitions attribute. It
+// returns nil when the value is absent, unknown or not valid JSON.
+func parseContainerDefinitions(data map[string]interface{}) []containerDefinition {
+	raw := stringField(data, "container_definitions")
+	if raw == "" {
+		return nil
+	}
+	var defs []containerDefinition
+	if err := json.Unmarshal([]byte(raw), &defs); err != nil {
+		return nil
+	}
+	return defs
+}
+
+// imageRef splits an image reference into its repository, tag and digest.
+func imageRef(image string) (repo, tag, digest string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, digest = image[:i], image[i+1:]
+	}
+	// A tag follows the last colon after the last slash; earlier colons
+	// belong to a registry port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:], digest
+	}
+	return image, "", digest
+}
+
+// isMutableImage reports whether an image is referenced by a tag that can be
+// moved: no digest, and "latest" or no tag at all.
+func isMutableImage(image string) bool {
+	_, tag, digest := imageRef(image)
+	return digest == "" && (tag == "" || tag == "latest")
+}
+
+func checkTaskDefinition(rc plan.ResourceChange) []RuleFinding {
+	action := rc.Change.Actions.ActionType()
+	if action != plan.ActionCreate && action != plan.ActionUpdate && action != plan.ActionReplace {
+		return nil
+	}
+	afterData := getAfterState(rc)
+	if afterData == nil {
+		return nil
+	}
+	beforeData := getBeforeState(rc)
+
+	after := parseContainerDefinitions(afterData)
+	before := make(map[string]containerDefinition)
+	for _, c := range parseContainerDefinitions(beforeData) {
+		before[c.Name] = c
+	}
+
+	var findings []RuleFinding
+	var images, plaintext, resources []string
+	for _, c := range after {
+		prev, existed := before[c.Name]
+
+		if existed && c.Image != prev.Image {
+			_, _, prevDigest := imageRef(prev.Image)
+			_, _, digest := imageRef(c.Image)
+			switch {
+			case prevDigest != "" && digest == "":
+				images = append(images, fmt.Sprintf("%s: image %q → %q drops the digest", c.Name, prev.Image, c.Image))
+			case isMutableImage(c.Image) && !isMutableImage(prev.Image):
+				images = append(images, fmt.Sprintf("%s: image %q → %q uses a mutable tag", c.Name, prev.Image, c.Image))
+			}
+		}
+
+		prevEnv := make(map[string]string)
+		for _, e := range prev.Environment {
+			prevEnv[e.Name] = e.Value
+		}
+		for _, e := range c.Environment {
+			if e.Value == "" || !secretEnvName.MatchString(e.Name) {
+				continue
+			}
+			if old, ok := prevEnv[e.Name]; ok && old == e.Value {
+				continue
+			}
+			plaintext = append(plaintext, fmt.Sprintf("%s: environment variable %s", c.Name, e.Name))
+		}
+
+		if existed {
+			for _, res := range []struct {
+				name       string
+				prev, next int
+			}{
+				{"cpu", prev.CPU, c.CPU},
+				{"memory", prev.Memory, c.Memory},
+				{"memoryReservation", prev.MemoryReservation, c.MemoryReservation},
+			} {
+				if res.prev > 0 && res.next > 0 && res.next < res.prev {
+					resources = append(resources, fmt.Sprintf("%s: %s %d → %d", c.Name, res.name, res.prev, res.next))
+				}
+			}
+		}
+	}
+	if beforeData != nil {
+		for _, attr := range []string{"cpu", "memory"} {
+			prev, _ := strconv.Atoi(stringField(beforeData, attr))
+			next, _ := strconv.Atoi(stringField(afterData, attr))
+			if prev > 0 && next > 0 && next < prev {
+				resources = append(resources, fmt.Sprintf("task %s: %d → %d", attr, prev, next))
+			}
+		}
+	}
+
+	if len(plaintext) > 0 {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"security"},
+			Title:    fmt.Sprintf("Secret-like values in plaintext environment of %s", rc.Address),
+			Address:  rc.Address,
+			Why: append(plaintext,
+				"Environment values are visible to anyone who can describe the task definition, and are stored in Terraform state",
+			),
+			Recommendations: []string{
+				"Move the values to Secrets Manager or SSM Parameter Store and reference them with secrets[].valueFrom",
+				"Rotate any credential that was committed in plaintext",
+			},
+		})
+	}
+	if len(images) > 0 {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"ops", "security"},
+			Title:    fmt.Sprintf("ECS task definition %s switches to mutable image references", rc.Address),
+			Address:  rc.Address,
+			Why: append(images,
+				"The image that runs can change without a Terraform change, and tasks of one deployment may run different images",
+			),
+			Recommendations: []string{
+				"Pin images by digest (repo@sha256:...) or an immutable version tag",
+				"Enable tag immutability on the ECR repository",
+			},
+		})
+	}
+	if len(resources) > 0 {
+		findings = append(findings, RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"capacity"},
+			Title:    fmt.Sprintf("ECS task definition %s reduces CPU or memory", rc.Address),
+			Address:  rc.Address,
+			Why: append(resources,
+				"Tasks may be throttled or killed for exceeding memory under the same load",
+			),
+			Recommendations: []string{
+				"Check CPU and memory utilization metrics before reducing",
+			},
+		})
+	}
+	return findings
+}
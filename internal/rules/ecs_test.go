@@ -0,0 +1,78 @@
+package rules
+
+import "testing"
+
+func TestECSScaleDown(t *testing.T) {
+	findings := evaluateAll(t, "ecs_scale_down.json")
+	var hasCount, hasHealthy bool
+	for _, f := range findings {
+		for _, w := range f.Why {
+			if contains(w, "desired_count") && contains(w, "4") && contains(w, "1") {
+				hasCount = true
+			}
+			if contains(w, "deployment_minimum_healthy_percent") {
+				hasHealthy = true
+			}
+		}
+	}
+	if !hasCount {
+		t.Error("expected finding for desired_count decrease")
+	}
+	if !hasHealthy {
+		t.Error("expected finding for deployment_minimum_healthy_percent decrease")
+	}
+}
+
+func TestECSServiceReplace(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ECSRule{}, "ecs_service_task.json", "aws_ecs_service.api"),
+		wantFinding{"will be replaced", SeverityHigh, "load_balancer: [api:8080 (targetgroup/api-blue/abc)] → [api:9090 (targetgroup/api-green/def)]"})
+}
+
+func TestECSServiceUpdate(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ECSRule{}, "ecs_service_task.json", "aws_ecs_service.worker"),
+		wantFinding{"scaled to zero", SeverityHigh, "desired_count: 2 → 0"},
+		wantFinding{"deployment circuit breaker removed", SeverityMedium, ""},
+		wantFinding{"compute changes", SeverityMedium, "[FARGATE base=1 weight=1, FARGATE_SPOT weight=3]"})
+}
+
+func TestECSTaskDefinitionUpdate(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ECSRule{}, "ecs_service_task.json", "aws_ecs_task_definition.api"),
+		wantFinding{"Secret-like values in plaintext environment", SeverityHigh, "api: environment variable DB_PASSWORD"},
+		wantFinding{"switches to mutable image references", SeverityMedium, "drops the digest"},
+		wantFinding{"reduces CPU or memory", SeverityMedium, "task cpu: 1024 → 512"})
+}
+
+func TestECSTaskDefinitionPreexistingSecret(t *testing.T) {
+	// The plaintext token is unchanged; only the image tag is new.
+	checkFindings(t, evaluateAddress(t, &ECSRule{}, "ecs_service_task.json", "aws_ecs_task_definition.worker"),
+		wantFinding{"switches to mutable image references", SeverityMedium, `"registry.internal:5000/worker:latest" uses a mutable tag`})
+}
+
+func TestECSTaskDefinitionCreate(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &ECSRule{}, "ecs_service_task.json", "aws_ecs_task_definition.batch"),
+		wantFinding{"Secret-like values in plaintext environment", SeverityHigh, "batch: environment variable STRIPE_API_KEY"})
+}
+
+func TestImageRef(t *testing.T) {
+	tests := []struct {
+		image, repo, tag, digest string
+		mutable                  bool
+	}{
+		{"nginx", "nginx", "", "", true},
+		{"nginx:latest", "nginx", "latest", "", true},
+		{"nginx:1.27", "nginx", "1.27", "", false},
+		{"registry.internal:5000/app", "registry.internal:5000/app", "", "", true},
+		{"registry.internal:5000/app:2.0", "registry.internal:5000/app", "2.0", "", false},
+		{"repo/app@sha256:abc", "repo/app", "", "sha256:abc", false},
+		{"repo/app:latest@sha256:abc", "repo/app", "latest", "sha256:abc", false},
+	}
+	for _, tt := range tests {
+		repo, tag, digest := imageRef(tt.image)
+		if repo != tt.repo || tag != tt.tag || digest != tt.digest {
+			t.Errorf("imageRef(%q) = %q, %q, %q; want %q, %q, %q", tt.image, repo, tag, digest, tt.repo, tt.tag, tt.digest)
+		}
+		if got := isMutableImage(tt.image); got != tt.mutable {
+			t.Errorf("isMutableImage(%q) = %v, want %v", tt.image, got, tt.mutable)
+		}
+	}
+}
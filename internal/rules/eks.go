@@ -0,0 +1,499 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+var awsAuthTypes = map[string]bool{
+	"kubernetes_config_map":         true,
+	"kubernetes_config_map_v1":      true,
+	"kubernetes_config_map_v1_data": true,
+}
+
+// EKSRule detects risky EKS changes: control plane version jumps and
+// downgrades, node group replacement and scale-down, add-on version changes,
+// public endpoint access, and access changes that can lock administrators
+// out. As a plan rule it checks node group version skew and authentication
+// mode changes, which depend on several resources.
+type EKSRule struct{}
+
+func (r *EKSRule) ID() string { return "eks" }
+
+func (r *EKSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
+	if rc.Mode == "data" {
+		return nil
+	}
+	switch rc.Type {
+	case "aws_eks_cluster":
+		return checkEKSCluster(rc)
+	case "aws_eks_node_group":
+		return checkEKSNodeGroup(rc)
+	case "aws_eks_addon":
+		return checkEKSAddon(rc)
+	case "aws_eks_access_entry", "aws_eks_access_policy_association":
+		return checkEKSAccessRemoval(rc)
+	}
+	if awsAuthTypes[rc.Type] {
+		return checkAWSAuth(rc)
+	}
+	return nil
+}
+
+// eksMinor returns the minor version of a Kubernetes version such as "1.29".
+func eksMinor(version string) (int, bool) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	return minor, err == nil
+}
+
+// eksMaxNodeSkew is the number of minor versions nodes may lag behind the
+// control plane: three from Kubernetes 1.28, two before.
+func eksMaxNodeSkew(clusterMinor int) int {
+	if clusterMinor >= 28 {
+		return 3
+	}
+	return 2
+}
+
+func checkEKSCluster(rc plan.ResourceChange) []RuleFinding {
+	action := rc.Change.Actions.ActionType()
+	if action != plan.ActionCreate && action != plan.ActionUpdate {
+		return nil
+	}
+	before, after := getBeforeState(rc), getAfterState(rc)
+	if after == nil {
+		return nil
+	}
+
+	var findings []RuleFinding
+	prev, next := stringField(before, "version"), stringField(after, "version")
+	prevMinor, ok1 := eksMinor(prev)
+	nextMinor, ok2 := eksMinor(next)
+	if action == plan.ActionUpdate && ok1 && ok2 {
+		switch {
+		case nextMinor < prevMinor:
+			findings = append(findings, RuleFinding{
+				Severity: SeverityHigh,
+				Tags:     []string{"ops"},
+				Title:    fmt.Sprintf("EKS cluster %s version downgrade", rc.Address),
+				Address:  rc.Address,
+				Why: []string{
+					fmt.Sprintf("version: %s → %s", prev, next),
+					"EKS does not support downgrading the control plane; the apply fails",
+				},
+				Recommendations: []string{
+					"Keep the current version, or build a new cluster on the older version and migrate workloads",
+				},
+			})
+		case nextMinor > prevMinor+1:
+			findings = append(findings, RuleFinding{
+				Severity: SeverityHigh,
+				Tags:     []string{"ops"},
+				Title:    fmt.Sprintf("EKS cluster %s upgrade skips minor versions", rc.Address),
+				Address:  rc.Address,
+				Why: []string{
+					fmt.Sprintf("version: %s → %s (%d minor versions)", prev, next, nextMinor-prevMinor),
+					"EKS upgrades the control plane one minor version at a time; the apply fails",
+				},
+				Recommendations: []string{
+					fmt.Sprintf("Upgrade to 1.%d first, then continue one minor version per apply", prevMinor+1),
+					"Upgrade node groups and add-ons between control plane upgrades",
+				},
+			})
+		}
+	}
+
+	prevPublic, _ := firstBlock(before, "vpc_config")["endpoint_public_access"].(bool)
+	vpc := firstBlock(after, "vpc_config")
+	nextPublic, _ := vpc["endpoint_public_access"].(bool)
+	if nextPublic && !prevPublic {
+		cidrs := toStringSlice(vpc["public_access_cidrs"])
+		sev := SeverityMedium
+		if len(cidrs) == 0 || containsStr(cidrs, "0.0.0.0/0") {
+			sev = SeverityHigh
+			cidrs = []string{"0.0.0.0/0"}
+		}
+		why := fmt.Sprintf("vpc_config.endpoint_public_access: false → true (public_access_cidrs: %s)", strings.Join(cidrs, ", "))
+		if action == plan.ActionCreate {
+			why = fmt.Sprintf("vpc_config.endpoint_public_access = true (public_access_cidrs: %s)", strings.Join(cidrs, ", "))
+		}
+		findings = append(findings, RuleFinding{
+			Severity: sev,
+			Tags:     []string{"security", "network"},
+			Title:    fmt.Sprintf("EKS cluster %s API endpoint is publicly accessible", rc.Address),
+			Address:  rc.Address,
+			Why: []string{
+				why,
+				"The Kubernetes API server can be reached from the listed ranges; only authentication protects it",
+			},
+			Recommendations: []string{
+				"Use endpoint_private_access and reach the API through the VPC or a VPN",
+				"Or restrict public_access_cidrs to known egress addresses",
+			},
+		})
+	}
+	return findings
+}
+
+func checkEKSNodeGroup(rc plan.ResourceChange) []RuleFinding {
+	action := rc.Change.Actions.ActionType()
+	if action != plan.ActionUpdate && action != plan.ActionReplace {
+		return nil
+	}
+	before, after := getBeforeState(rc), getAfterState(rc)
+	if before == nil || after == nil {
+		return nil
+	}
+
+	var findings []RuleFinding
+	if action == plan.ActionReplace {
+		var why []string
+		for _, rp := range util.ExtractReplacePaths(rc.Change.ReplacePaths) {
+			why = append(why, fmt.Sprintf("replace triggered by: %s", rp))
+		}
+		why = append(why, "The node group is deleted before it is recreated: its nodes are drained and terminated, and pods wait for new capacity")
+		findings = append(findings, RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"downtime", "capacity"},
+			Title:    fmt.Sprintf("EKS node group %s will be replaced", rc.Address),
+			Address:  rc.Address,
+			Why:      why,
+			Recommendations: []string{
+				"Create the new node group alongside the old one (new name or node_group_name_prefix with create_before_destroy), then remove the old one",
+				"Check PodDisruptionBudgets so draining does not take down every replica",
+			},
+		})
+	}
+
+	prevScaling, nextScaling := firstBlock(before, "scaling_config"), firstBlock(after, "scaling_config")
+	var reduced []string
+	for _, key := range []string{"desired_size", "min_size", "max_size"} {
+		p, n := intFromJSON(prevScaling[key]), intFromJSON(nextScaling[key])
+		if _, known := nextScaling[key]; known && n < p {
+			reduced = append(reduced, fmt.Sprintf("scaling_config.%s: %d → %d", key, p, n))
+		}
+	}
+	if len(reduced) > 0 {
+		f := RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"capacity"},
+			Title:    fmt.Sprintf("EKS node group %s scaling_config reduced", rc.Address),
+			Address:  rc.Address,
+			Why:      reduced,
+			Recommendations: []string{
+				"Verify the remaining nodes can schedule current workloads",
+				"If the Cluster Autoscaler or Karpenter manages size, ignore_changes on desired_size avoids fighting it",
+			},
+		}
+		if _, known := nextScaling["desired_size"]; known && intFromJSON(prevScaling["desired_size"]) > 0 && intFromJSON(nextScaling["desired_size"]) == 0 {
+			f.Severity = SeverityHigh
+			f.Tags = []string{"downtime", "capacity"}
+			f.Title = fmt.Sprintf("EKS node group %s scaled to zero", rc.Address)
+			f.Why = append(f.Why, "All nodes are terminated; pods scheduled only on this node group stop")
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func checkEKSAddon(rc plan.ResourceChange) []RuleFinding {
+	if rc.Change.Actions.ActionType() != plan.ActionUpdate {
+		return nil
+	}
+	before, after := getBeforeState(rc), getAfterState(rc)
+	prev, next := stringField(before, "addon_version"), stringField(after, "addon_version")
+	if prev == "" || next == "" || prev == next {
+		return nil
+	}
+	name := stringField(after, "addon_name")
+	why := []string{fmt.Sprintf("addon_version: %s → %s", prev, next)}
+	switch name {
+	case "vpc-cni", "coredns", "kube-proxy":
+		why = append(why, fmt.Sprintf("%s is a core add-on: pod networking or DNS is affected while it rolls out", name))
+	}
+	if stringField(after, "resolve_conflicts_on_update") == "OVERWRITE" {
+		why = append(why, "resolve_conflicts_on_update = OVERWRITE: custom changes to the add-on's resources are lost")
+	}
+	return []RuleFinding{{
+		Severity: SeverityMedium,
+		Tags:     []string{"ops"},
+		Title:    fmt.Sprintf("EKS add-on %s version changes on %s", name, rc.Address),
+		Address:  rc.Address,
+		Why:      why,
+		Recommendations: []string{
+			"Check the add-on version is compatible with the cluster version (aws eks describe-addon-versions)",
+			"Upgrade add-ons one minor version at a time, after the control plane",
+		},
+	}}
+}
+
+// eksAdminPolicies are access policies that grant cluster administration.
+var eksAdminPolicies = []string{"AmazonEKSClusterAdminPolicy", "AmazonEKSAdminPolicy"}
+
+func checkEKSAccessRemoval(rc plan.ResourceChange) []RuleFinding {
+	if rc.Change.Actions.ActionType() != plan.ActionDelete {
+		return nil
+	}
+	before := getBeforeState(rc)
+	principal := stringField(before, "principal_arn")
+	cluster := stringField(before, "cluster_name")
+
+	if rc.Type == "aws_eks_access_entry" {
+		return []RuleFinding{{
+			Severity: SeverityMedium,
+			Tags:     []string{"security", "ops"},
+			Title:    fmt.Sprintf("EKS access entry %s is deleted", rc.Address),
+			Address:  rc.Address,
+			Why: []string{
+				fmt.Sprintf("%s loses access to cluster %s", principal, cluster),
+			},
+			Recommendations: []string{
+				"Make sure another administrator keeps access to the cluster",
+			},
+		}}
+	}
+
+	policy := stringField(before, "policy_arn")
+	for _, p := range eksAdminPolicies {
+		if strings.HasSuffix(policy, "/"+p) {
+			return []RuleFinding{{
+				Severity: SeverityHigh,
+				Tags:     []string{"security", "ops"},
+				Title:    fmt.Sprintf("EKS cluster admin access removed for %s", principal),
+				Address:  rc.Address,
+				Why: []string{
+					fmt.Sprintf("%s is disassociated from %s on cluster %s", p, principal, cluster),
+				},
+				Recommendations: []string{
+					"Make sure another principal keeps an admin policy association before applying",
+				},
+			}}
+		}
+	}
+	return nil
+}
+
+// awsAuthIdentity is one mapRoles or mapUsers entry of the aws-auth ConfigMap.
+type awsAuthIdentity struct {
+	ARN     string
+	Masters bool
+}
+
+var awsAuthARN = regexp.MustCompile(`"?(?:rolearn|userarn)"?\s*:\s*"?([^"\s]+)`)
+
+// awsAuthIdentities extracts identities from the mapRoles and mapUsers YAML
+// of an aws-auth ConfigMap. Entries are split on their top-level list items;
+// this covers both hand-written YAML and yamlencode output.
+func awsAuthIdentities(data map[string]interface{}) map[string]awsAuthIdentity {
+	m, _ := data["data"].(map[string]interface{})
+	ids := make(map[string]awsAuthIdentity)
+	for _, key := range []string{"mapRoles", "mapUsers"} {
+		doc, _ := m[key].(string)
+		for _, item := range yamlListItems(doc) {
+			match := awsAuthARN.FindStringSubmatch(item)
+			if match == nil {
+				continue
+			}
+			ids[match[1]] = awsAuthIdentity{ARN: match[1], Masters: strings.Contains(item, "system:masters")}
+		}
+	}
+	return ids
+}
+
+// yamlListItems splits a YAML sequence into its top-level items: lines
+// starting with "- " at the smallest indentation used for list items.
+func yamlListItems(doc string) []string {
+	lines := strings.Split(doc, "\n")
+	indent := -1
+	for _, l := range lines {
+		trimmed := strings.TrimLeft(l, " ")
+		if strings.HasPrefix(trimmed, "- ") {
+			if n := len(l) - len(trimmed); indent < 0 || n < indent {
+				indent = n
+			}
+		}
+	}
+	var items []string
+	var cur []string
+	for _, l := range lines {
+		trimmed := strings.TrimLeft(l, " ")
+		if len(l)-len(trimmed) == indent && strings.HasPrefix(trimmed, "- ") && len(cur) > 0 {
+			items = append(items, strings.Join(cur, "\n"))
+			cur = nil
+		}
+		cur = append(cur, l)
+	}
+	if len(cur) > 0 {
+		items = append(items, strings.Join(cur, "\n"))
+	}
+	return items
+}
+
+func isAWSAuth(data map[string]interface{}) bool {
+	meta := firstBlock(data, "metadata")
+	return stringField(meta, "name") == "aws-auth" && stringField(meta, "namespace") == "kube-system"
+}
+
+func checkAWSAuth(rc plan.ResourceChange) []RuleFinding {
+	action := rc.Change.Actions.ActionType()
+	if action != plan.ActionUpdate && action != plan.ActionReplace && action != plan.ActionDelete {
+		return nil
+	}
+	before, after := getBeforeState(rc), getAfterState(rc)
+	if !isAWSAuth(before) {
+		return nil
+	}
+
+	if action == plan.ActionDelete || action == plan.ActionReplace {
+		return []RuleFinding{{
+			Severity: SeverityHigh,
+			Tags:     []string{"security", "ops"},
+			Title:    fmt.Sprintf("aws-auth ConfigMap %s will be %sd", rc.Address, action),
+			Address:  rc.Address,
+			Why: []string{
+				"IAM roles and users mapped in aws-auth, including node roles, lose access to the cluster until it is recreated",
+			},
+			Recommendations: []string{
+				"Update the ConfigMap in place, or move administrators to EKS access entries first",
+			},
+		}}
+	}
+
+	prev, next := awsAuthIdentities(before), awsAuthIdentities(after)
+	arns := make([]string, 0, len(prev))
+	for arn := range prev {
+		if _, ok := next[arn]; !ok {
+			arns = append(arns, arn)
+		}
+	}
+	sort.Strings(arns)
+
+	var removed []string
+	masters := false
+	for _, arn := range arns {
+		line := arn
+		if prev[arn].Masters {
+			line += " (system:masters)"
+			masters = true
+		}
+		removed = append(removed, line)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	sev := SeverityMedium
+	if masters {
+		sev = SeverityHigh
+	}
+	return []RuleFinding{{
+		Severity: sev,
+		Tags:     []string{"security", "ops"},
+		Title:    fmt.Sprintf("aws-auth ConfigMap removes cluster access on %s", rc.Address),
+		Address:  rc.Address,
+		Why:      append([]string{"Identities removed from mapRoles/mapUsers:"}, removed...),
+		Recommendations: []string{
+			"Make sure another identity keeps system:masters or an admin access entry",
+		},
+	}}
+}
+
+// EvaluatePlan checks node group version skew against control plane
+// upgrades, and authentication mode changes against the access entries that
+// remain for the cluster.
+func (r *EKSRule) EvaluatePlan(x *plan.Index) []RuleFinding {
+	var findings []RuleFinding
+	for _, cluster := range x.ByType("aws_eks_cluster") {
+		if cluster.Mode == "data" || cluster.Change.Actions.ActionType() != plan.ActionUpdate {
+			continue
+		}
+		before, after := getBeforeState(*cluster), getAfterState(*cluster)
+		name := stringField(after, "name")
+		if f, ok := eksNodeSkew(x, cluster.Address, name, before, after); ok {
+			findings = append(findings, f)
+		}
+		if f, ok := eksAuthModeChange(x, cluster.Address, name, before, after); ok {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func eksNodeSkew(x *plan.Index, address, name string, before, after map[string]interface{}) (RuleFinding, bool) {
+	prevMinor, ok1 := eksMinor(stringField(before, "version"))
+	nextMinor, ok2 := eksMinor(stringField(after, "version"))
+	if !ok1 || !ok2 || nextMinor <= prevMinor {
+		return RuleFinding{}, false
+	}
+	skew := eksMaxNodeSkew(nextMinor)
+	var lagging []string
+	for _, ng := range x.ByType("aws_eks_node_group") {
+		data := getAfterState(*ng)
+		if ng.Change.Actions.ActionType() == plan.ActionDelete || stringField(data, "cluster_name") != name {
+			continue
+		}
+		v := stringField(data, "version")
+		if minor, ok := eksMinor(v); ok && nextMinor-minor > skew {
+			lagging = append(lagging, fmt.Sprintf("%s: %s (%d minor versions behind)", ng.Address, v, nextMinor-minor))
+		}
+	}
+	if len(lagging) == 0 {
+		return RuleFinding{}, false
+	}
+	why := append([]string{fmt.Sprintf("Control plane: %s → %s; nodes may lag at most %d minor versions", stringField(before, "version"), stringField(after, "version"), skew)}, lagging...)
+	return RuleFinding{
+		Severity: SeverityHigh,
+		Tags:     []string{"ops", "downtime"},
+		Title:    fmt.Sprintf("EKS cluster %s upgrade exceeds the supported node version skew", address),
+		Address:  address,
+		Why:      why,
+		Recommendations: []string{
+			"Upgrade the listed node groups before the control plane",
+		},
+	}, true
+}
+
+func eksAuthModeChange(x *plan.Index, address, name string, before, after map[string]interface{}) (RuleFinding, bool) {
+	prev := stringField(firstBlock(before, "access_config"), "authentication_mode")
+	next := stringField(firstBlock(after, "access_config"), "authentication_mode")
+	if next != "API" || prev == "API" {
+		return RuleFinding{}, false
+	}
+	if prev == "" {
+		prev = "CONFIG_MAP"
+	}
+
+	entries := 0
+	for _, e := range x.ByType("aws_eks_access_entry") {
+		if e.Change.Actions.ActionType() != plan.ActionDelete && stringField(getAfterState(*e), "cluster_name") == name {
+			entries++
+		}
+	}
+	f := RuleFinding{
+		Severity: SeverityMedium,
+		Tags:     []string{"security", "ops"},
+		Title:    fmt.Sprintf("EKS cluster %s stops reading the aws-auth ConfigMap", address),
+		Address:  address,
+		Why: []string{
+			fmt.Sprintf("access_config.authentication_mode: %s → API", prev),
+			"Identities mapped only in aws-auth lose access, and the change cannot be reverted",
+			fmt.Sprintf("%d access entries for the cluster are in the plan", entries),
+		},
+		Recommendations: []string{
+			"Switch to API_AND_CONFIG_MAP first and create access entries for every aws-auth mapping",
+		},
+	}
+	if entries == 0 {
+		f.Severity = SeverityHigh
+	}
+	return f, true
+}
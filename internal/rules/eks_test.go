@@ -0,0 +1,114 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+func TestEKSClusterUpgradeSkipsVersions(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_cluster.prod"),
+		wantFinding{"upgrade skips minor versions", SeverityHigh, "version: 1.27 → 1.29 (2 minor versions)"},
+		wantFinding{"API endpoint is publicly accessible", SeverityHigh, "false → true (public_access_cidrs: 0.0.0.0/0)"})
+}
+
+func TestEKSClusterDowngrade(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_cluster.staging"),
+		wantFinding{"version downgrade", SeverityHigh, "version: 1.30 → 1.29"})
+}
+
+func TestEKSClusterRestrictedPublicEndpoint(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_cluster.dev"),
+		wantFinding{"API endpoint is publicly accessible", SeverityMedium, "203.0.113.0/24"})
+}
+
+func TestEKSNodeGroupUnchanged(t *testing.T) {
+	if findings := evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_node_group.legacy"); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestEKSNodeGroupReplace(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_node_group.general"),
+		wantFinding{"will be replaced", SeverityHigh, "replace triggered by: instance_types"})
+}
+
+func TestEKSNodeGroupScaledToZero(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_node_group.batch"),
+		wantFinding{"scaled to zero", SeverityHigh, "scaling_config.desired_size: 3 → 0"})
+}
+
+func TestEKSNodeGroupScaleDown(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_node_group.web"),
+		wantFinding{"scaling_config reduced", SeverityMedium, "scaling_config.max_size: 10 → 6"})
+}
+
+func TestEKSAddonVersion(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_addon.coredns"),
+		wantFinding{"EKS add-on coredns version changes", SeverityMedium, "resolve_conflicts_on_update = OVERWRITE"})
+}
+
+func TestEKSAccessEntryCreate(t *testing.T) {
+	if findings := evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_access_entry.ci"); len(findings) != 0 {
+		t.Errorf("expected no findings for a new access entry, got %v", findings)
+	}
+}
+
+func TestEKSAccessEntryDelete(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_access_entry.old_admin"),
+		wantFinding{"EKS access entry aws_eks_access_entry.old_admin is deleted", SeverityMedium, "role/old-admin loses access to cluster prod"})
+}
+
+func TestEKSAccessPolicyAssociationDelete(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "aws_eks_access_policy_association.old_admin"),
+		wantFinding{"EKS cluster admin access removed for arn:aws:iam::123456789012:role/old-admin", SeverityHigh, "AmazonEKSClusterAdminPolicy"})
+}
+
+func TestEKSAWSAuthRemovesAccess(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "kubernetes_config_map_v1_data.aws_auth"),
+		wantFinding{"aws-auth ConfigMap removes cluster access", SeverityHigh, "role/platform-admin (system:masters)"})
+}
+
+func TestEKSAWSAuthDelete(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &EKSRule{}, "eks_upgrades.json", "kubernetes_config_map.aws_auth_legacy"),
+		wantFinding{"aws-auth ConfigMap kubernetes_config_map.aws_auth_legacy will be deleted", SeverityHigh, ""})
+}
+
+func TestEKSPlanChecks(t *testing.T) {
+	p := loadTestPlan(t, "eks_upgrades.json")
+	findings := (&EKSRule{}).EvaluatePlan(plan.NewIndex(p))
+
+	want := []struct {
+		address string
+		title   string
+		sev     int
+		why     string
+	}{
+		{"aws_eks_cluster.prod", "EKS cluster aws_eks_cluster.prod upgrade exceeds the supported node version skew", SeverityHigh,
+			"aws_eks_node_group.legacy: 1.25 (4 minor versions behind)"},
+		{"aws_eks_cluster.prod", "EKS cluster aws_eks_cluster.prod stops reading the aws-auth ConfigMap", SeverityHigh,
+			"0 access entries for the cluster are in the plan"},
+		{"aws_eks_cluster.staging", "EKS cluster aws_eks_cluster.staging stops reading the aws-auth ConfigMap", SeverityMedium,
+			"access_config.authentication_mode: API_AND_CONFIG_MAP → API"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %v", len(want), len(findings), findings)
+	}
+	for i, w := range want {
+		f := findings[i]
+		if f.Address != w.address || f.Title != w.title || f.Severity != w.sev {
+			t.Errorf("finding %d: expected %s %s %q, got %s %s %q", i, severityName(w.sev), w.address, w.title, severityName(f.Severity), f.Address, f.Title)
+		}
+		if !anyContains(f.Why, w.why) {
+			t.Errorf("finding %d: expected why containing %q, got %v", i, w.why, f.Why)
+		}
+	}
+}
+
+func TestYAMLListItems(t *testing.T) {
+	doc := "- rolearn: a\n  groups:\n    - system:masters\n- rolearn: b\n  groups:\n    - dev\n"
+	items := yamlListItems(doc)
+	if len(items) != 2 || !contains(items[0], "system:masters") || contains(items[1], "system:masters") {
+		t.Errorf("unexpected items: %q", items)
+	}
+}
@@ -7,8 +7,13 @@ import (
 	"github.com/djeeteg007/tf-why/internal/util"
 )
 
-// GenericRule handles replace and delete for any resource type.
-type GenericRule struct{}
+// GenericRule handles replace and delete for any resource type. Types in
+// the Stateful catalog are reported as data loss.
+type GenericRule struct {
+	Stateful map[string]*StatefulResource
+}
+
+func (r *GenericRule) ID() string { return "generic" }
 
 func (r *GenericRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 	action := rc.Change.Actions.ActionType()
@@ -37,38 +42,52 @@ func (r *GenericRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 		whys = append(whys, fmt.Sprintf("replace triggered by: %s", rp))
 	}
 
+	stateful := r.Stateful[rc.Type]
+	subject := "Resource"
+	if stateful != nil {
+		subject = "Stateful resource"
+	}
+
+	var f RuleFinding
 	if action == plan.ActionReplace {
 		if len(whys) == 0 {
 			whys = []string{"Resource will be destroyed and recreated"}
 		}
-		return []RuleFinding{{
+		f = RuleFinding{
 			Severity: SeverityHigh,
 			Tags:     []string{"downtime"},
-			Title:    fmt.Sprintf("Resource %s will be replaced (destroy + recreate)", rc.Address),
+			Title:    fmt.Sprintf("%s %s will be replaced (destroy + recreate)", subject, rc.Address),
 			Address:  rc.Address,
 			Why:      whys,
 			Recommendations: []string{
 				"Confirm rollback plan; expect downtime",
 				"Verify no dependent resources will break",
 			},
-		}}
+		}
+	} else {
+		if len(whys) == 0 {
+			whys = []string{"Resource will be destroyed"}
+		}
+		f = RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"ops"},
+			Title:    fmt.Sprintf("%s %s will be deleted", subject, rc.Address),
+			Address:  rc.Address,
+			Why:      whys,
+			Recommendations: []string{
+				"Confirm resource is safe to destroy",
+				"Check for dependent resources or data loss",
+			},
+		}
 	}
 
-	// Delete
-	if len(whys) == 0 {
-		whys = []string{"Resource will be destroyed"}
+	if stateful != nil {
+		f.Tags = append(f.Tags, "data")
+		f.Why = append(f.Why, stateful.snapshotState(getBeforeState(rc))...)
+		f.Why = append(f.Why, fmt.Sprintf("%s is a stateful resource: the data it holds is lost when it is %sd", rc.Type, action))
+		f.Recommendations = append(append([]string(nil), stateful.Recommendations...), f.Recommendations...)
 	}
-	return []RuleFinding{{
-		Severity: SeverityHigh,
-		Tags:     []string{"ops"},
-		Title:    fmt.Sprintf("Resource %s will be deleted", rc.Address),
-		Address:  rc.Address,
-		Why:      whys,
-		Recommendations: []string{
-			"Confirm resource is safe to destroy",
-			"Check for dependent resources or data loss",
-		},
-	}}
+	return []RuleFinding{f}
 }
 
 // isHandledBySpecificRule returns true if the resource type + action
@@ -84,6 +103,26 @@ func isHandledBySpecificRule(resourceType string, action plan.ActionKind) bool {
 	case "aws_route", "aws_route_table", "aws_network_acl",
 		"aws_lb_listener", "aws_lb_listener_rule", "aws_nat_gateway":
 		return true
+	// ECS services — ECSRule handles replace
+	case "aws_ecs_service":
+		if action == plan.ActionReplace {
+			return true
+		}
+	// EKS node groups — EKSRule handles replace
+	case "aws_eks_node_group":
+		if action == plan.ActionReplace {
+			return true
+		}
+	// EC2 instances — ComputeRule handles replace
+	case "aws_instance":
+		if action == plan.ActionReplace {
+			return true
+		}
+	// DynamoDB tables and replicas — DynamoDBRule handles replace
+	case "aws_dynamodb_table", "aws_dynamodb_table_replica":
+		if action == plan.ActionReplace {
+			return true
+		}
 	// KMS resources — KMSRule handles replace/delete
 	case "aws_kms_key", "aws_kms_alias":
 		return true
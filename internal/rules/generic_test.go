@@ -0,0 +1,93 @@
+package rules
+
+import "testing"
+
+func TestGenericReplace(t *testing.T) {
+	findings := evaluateAll(t, "generic_replace.json")
+	if len(findings) == 0 {
+		t.Fatal("expected findings for replace")
+	}
+	found := false
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && containsTag(f.Tags, "downtime") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("expected HIGH severity with downtime tag for replace")
+	}
+}
+
+func TestGenericDelete(t *testing.T) {
+	findings := evaluateAll(t, "generic_delete.json")
+	if len(findings) == 0 {
+		t.Fatal("expected findings for delete")
+	}
+	found := false
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && containsTag(f.Tags, "ops") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("expected HIGH severity with ops tag for delete")
+	}
+}
+
+func TestGenericStatefulResources(t *testing.T) {
+	p := loadTestPlan(t, "stateful_resources.json")
+	rule := &GenericRule{Stateful: DefaultStatefulResources()}
+
+	tests := []struct {
+		address string
+		title   string
+		tags    []string
+		why     string // substring of some Why line, "" to skip
+		rec     string // expected first recommendation
+	}{
+		{"aws_docdb_cluster.catalog", "Stateful resource aws_docdb_cluster.catalog will be deleted", []string{"ops", "data"},
+			"skip_final_snapshot = true", "Set skip_final_snapshot = false and a final_snapshot_identifier, and apply that first"},
+		{"aws_elasticache_replication_group.sessions", "Stateful resource aws_elasticache_replication_group.sessions will be replaced (destroy + recreate)", []string{"downtime", "data"},
+			`final_snapshot_identifier = "sessions-final"`, "Set final_snapshot_identifier so a snapshot is taken before deletion"},
+		{"aws_sqs_queue.jobs", "Stateful resource aws_sqs_queue.jobs will be deleted", []string{"ops", "data"},
+			"message_retention_seconds = 1209600", "Drain the queue (ApproximateNumberOfMessagesVisible = 0) before deleting it"},
+		{"aws_sns_topic.alerts", "Resource aws_sns_topic.alerts will be deleted", []string{"ops"},
+			"", "Confirm resource is safe to destroy"},
+		// The dynamodb and rds rules report replaces; plain deletes are
+		// reported here.
+		{"aws_dynamodb_table.orders", "Stateful resource aws_dynamodb_table.orders will be deleted", []string{"ops", "data"},
+			"point_in_time_recovery.enabled = true", "Take an on-demand backup or export the table to S3 before deleting it; with point-in-time recovery enabled, AWS keeps a system backup for 35 days"},
+		{"aws_db_instance.main", "Stateful resource aws_db_instance.main will be deleted", []string{"ops", "data"},
+			`final_snapshot_identifier = "main-final"`, "Set skip_final_snapshot = false and a final_snapshot_identifier, and apply that first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			findings := rule.Evaluate(findChange(t, p, tt.address))
+			checkFindings(t, findings, wantFinding{tt.title, SeverityHigh, tt.why})
+			f := findings[0]
+			if len(f.Tags) != len(tt.tags) {
+				t.Errorf("expected tags %v, got %v", tt.tags, f.Tags)
+			}
+			for _, tag := range tt.tags {
+				if !containsTag(f.Tags, tag) {
+					t.Errorf("expected tags %v, got %v", tt.tags, f.Tags)
+				}
+			}
+			if f.Recommendations[0] != tt.rec {
+				t.Errorf("expected first recommendation %q, got %v", tt.rec, f.Recommendations)
+			}
+		})
+	}
+}
+
+func TestGenericStatefulCatalogEntryRemoved(t *testing.T) {
+	// Removing a type from the catalog restores the plain finding.
+	catalog := DefaultStatefulResources()
+	catalog["aws_sqs_queue"] = nil
+	findings := evaluateAddress(t, &GenericRule{Stateful: catalog}, "stateful_resources.json", "aws_sqs_queue.jobs")
+	if len(findings) != 1 || containsTag(findings[0].Tags, "data") {
+		t.Errorf("expected no data tag for a removed catalog entry, got %v", findings)
+	}
+}
@@ -3,29 +3,33 @@ package rules
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/djeeteg007/tf-why/internal/plan"
 )
 
-var iamTypes = map[string]bool{
-	"aws_iam_policy":                    true,
-	"aws_iam_role_policy":               true,
-	"aws_iam_user_policy":               true,
-	"aws_s3_bucket_policy":              true,
-	"aws_s3_bucket_public_access_block": true,
+// IAMPolicyRule detects dangerous changes to IAM and resource-based policies.
+type IAMPolicyRule struct {
+	// TrustedAccounts are account IDs not reported as cross-account principals.
+	TrustedAccounts []string
+	// OwnerAccounts are the accounts owning resources in the plan, which
+	// principals may also reference.
+	OwnerAccounts []string
+	// ReportPreexisting keeps risks already present before an update,
+	// downgraded to LOW, instead of dropping them.
+	ReportPreexisting bool
 }
 
-// IAMPolicyRule detects dangerous IAM and bucket policy changes.
-type IAMPolicyRule struct{}
+func (r *IAMPolicyRule) ID() string { return "iam-policy" }
 
 func (r *IAMPolicyRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
-	if !iamTypes[rc.Type] {
+	if !carriesPolicy(rc) && rc.Type != "aws_s3_bucket_public_access_block" {
 		return nil
 	}
 
 	action := rc.Change.Actions.ActionType()
-	if action == plan.ActionNoop || action == plan.ActionRead {
+	if action == plan.ActionNoop || (action == plan.ActionRead && rc.Mode != "data") {
 		return nil
 	}
 
@@ -37,11 +41,30 @@ func (r *IAMPolicyRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 		return nil
 	}
 
-	// Check for policy document (could be in "policy" or "document" field).
-	policyJSON := extractPolicyJSON(afterData)
-	if policyJSON != "" {
-		policyFindings := analyzePolicyDocument(policyJSON, rc.Address)
-		findings = append(findings, policyFindings...)
+	// Documents in the before state, by path, so that updates only report
+	// what the change introduces.
+	before := make(map[string]string)
+	for _, src := range policySources(rc, getBeforeState(rc)) {
+		before[src.Path] = src.JSON
+	}
+
+	known := knownAccounts(r.TrustedAccounts, r.OwnerAccounts, afterData, getBeforeState(rc))
+	for _, src := range policySources(rc, afterData) {
+		risks := policyRisks(src.JSON, rc.Address, known)
+
+		var docFindings []RuleFinding
+		if beforeJSON := before[src.Path]; beforeJSON != "" {
+			risks = newRisks(risks, policyRisks(beforeJSON, rc.Address, known), r.ReportPreexisting)
+			docFindings = append(docFindings, policyDeltaFinding(beforeJSON, src.JSON, rc.Address)...)
+		}
+		docFindings = append(docFindings, riskFindings(risks)...)
+
+		if labelsPolicyPath(rc.Type) {
+			for i := range docFindings {
+				docFindings[i].Title += fmt.Sprintf(" (%s)", src.Path)
+			}
+		}
+		findings = append(findings, docFindings...)
 	}
 
 	// For s3_bucket_public_access_block, check if protections are being removed.
@@ -52,6 +75,94 @@ func (r *IAMPolicyRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 	return findings
 }
 
+// knownAccounts returns the accounts that principals may reference without a
+// cross-account finding: the trusted accounts, the accounts owning resources
+// in the plan, and the account owning the resource itself, read from its
+// before or after state. It returns nil when none is known; the resource's
+// own account then cannot be told apart from a foreign one, and foreign
+// accounts are reported at LOW.
+func knownAccounts(trusted, owners []string, states ...map[string]interface{}) map[string]bool {
+	known := make(map[string]bool, len(trusted)+len(owners)+1)
+	for _, a := range trusted {
+		known[a] = true
+	}
+	for _, a := range owners {
+		known[a] = true
+	}
+	for _, data := range states {
+		if acct := ownerAccount(data); acct != "" {
+			known[acct] = true
+		}
+	}
+	if len(known) == 0 {
+		return nil
+	}
+	return known
+}
+
+// unknownOwnerWhy explains a cross-account finding reported while no account
+// is known.
+const unknownOwnerWhy = "No account owning a resource in the plan is known and rules.trusted_accounts is empty, so this may be the resource's own account"
+
+// ownerAccount returns the account owning a resource, from its ARN, its
+// owner attribute or an SQS queue URL, or "".
+func ownerAccount(data map[string]interface{}) string {
+	if acct := accountFromPrincipal(stringField(data, "arn")); acct != "" {
+		return acct
+	}
+	if owner := stringField(data, "owner"); isAccountID(owner) {
+		return owner
+	}
+	// https://sqs.<region>.amazonaws.com/<account>/<queue>
+	if parts := strings.Split(stringField(data, "queue_url"), "/"); len(parts) >= 5 && isAccountID(parts[3]) {
+		return parts[3]
+	}
+	return ""
+}
+
+// PlanAccounts returns the accounts owning the managed resources of a plan,
+// read from their ARNs in the plan and prior state, and the account_id of
+// aws_caller_identity data sources.
+func PlanAccounts(p *plan.Plan) []string {
+	seen := make(map[string]bool)
+	var accounts []string
+	add := func(mode, resourceType string, raw json.RawMessage) {
+		var data map[string]interface{}
+		if json.Unmarshal(raw, &data) != nil || data == nil {
+			return
+		}
+		acct := ""
+		switch {
+		case mode == "data" && resourceType == "aws_caller_identity":
+			acct = stringField(data, "account_id")
+		case mode != "data":
+			acct = ownerAccount(data)
+		}
+		if isAccountID(acct) && !seen[acct] {
+			seen[acct] = true
+			accounts = append(accounts, acct)
+		}
+	}
+	for _, rc := range p.ResourceChanges {
+		add(rc.Mode, rc.Type, rc.Change.Before)
+		add(rc.Mode, rc.Type, rc.Change.After)
+	}
+	if p.PriorState != nil && p.PriorState.Values != nil {
+		var walk func(m *plan.StateModule)
+		walk = func(m *plan.StateModule) {
+			for _, r := range m.Resources {
+				add(r.Mode, r.Type, r.Values)
+			}
+			for i := range m.ChildModules {
+				walk(&m.ChildModules[i])
+			}
+		}
+		walk(&p.PriorState.Values.RootModule)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
 func getAfterState(rc plan.ResourceChange) map[string]interface{} {
 	raw := rc.Change.After
 	if len(raw) == 0 || string(raw) == "null" {
@@ -64,112 +175,338 @@ func getAfterState(rc plan.ResourceChange) map[string]interface{} {
 	return m
 }
 
-func extractPolicyJSON(data map[string]interface{}) string {
-	// Try "policy" field first, then "document".
-	for _, key := range []string{"policy", "document"} {
-		if val, ok := data[key]; ok {
-			switch v := val.(type) {
-			case string:
-				return v
+func getBeforeState(rc plan.ResourceChange) map[string]interface{} {
+	raw := rc.Change.Before
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+type policyDocument struct {
+	Statement policyStatements `json:"Statement"`
+}
+
+// policyStatements accepts both a single statement object and a list.
+type policyStatements []policyStatement
+
+func (s *policyStatements) UnmarshalJSON(data []byte) error {
+	var list []policyStatement
+	if err := json.Unmarshal(data, &list); err == nil {
+		*s = list
+		return nil
+	}
+	var single policyStatement
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	*s = []policyStatement{single}
+	return nil
+}
+
+type policyStatement struct {
+	Sid         string                            `json:"Sid"`
+	Effect      string                            `json:"Effect"`
+	Action      interface{}                       `json:"Action"`
+	NotAction   interface{}                       `json:"NotAction"`
+	Resource    interface{}                       `json:"Resource"`
+	NotResource interface{}                       `json:"NotResource"`
+	Principal   interface{}                       `json:"Principal"`
+	Condition   map[string]map[string]interface{} `json:"Condition"`
+}
+
+func (s policyStatement) isAllow() bool {
+	return strings.EqualFold(s.Effect, "Allow")
+}
+
+// restrictingConditionKeys narrow a wildcard principal to a known account,
+// organization, source resource or network location.
+var restrictingConditionKeys = map[string]bool{
+	"aws:sourceaccount":     true,
+	"aws:sourcearn":         true,
+	"aws:sourceowner":       true,
+	"aws:principalorgid":    true,
+	"aws:principalorgpaths": true,
+	"aws:principalaccount":  true,
+	"aws:principalarn":      true,
+	"aws:sourcevpc":         true,
+	"aws:sourcevpce":        true,
+	"aws:sourceip":          true,
+}
+
+// hasRestrictingCondition reports whether any condition operator tests one of
+// restrictingConditionKeys.
+func (s policyStatement) hasRestrictingCondition() bool {
+	for _, kv := range s.Condition {
+		for key := range kv {
+			if restrictingConditionKeys[strings.ToLower(key)] {
+				return true
 			}
 		}
 	}
+	return false
+}
+
+// awsPrincipals returns the values of Principal.AWS, or ["*"] for a bare
+// wildcard principal.
+func (s policyStatement) awsPrincipals() []string {
+	switch p := s.Principal.(type) {
+	case string:
+		return []string{p}
+	case map[string]interface{}:
+		return toStringSlice(p["AWS"])
+	}
+	return nil
+}
+
+// accountFromPrincipal extracts the 12-digit account ID from an account ID
+// or ARN principal, or returns "".
+func accountFromPrincipal(p string) string {
+	if isAccountID(p) {
+		return p
+	}
+	if strings.HasPrefix(p, "arn:") {
+		parts := strings.Split(p, ":")
+		if len(parts) >= 5 && isAccountID(parts[4]) {
+			return parts[4]
+		}
+	}
 	return ""
 }
 
-type policyDocument struct {
-	Statement []policyStatement `json:"Statement"`
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
 
-type policyStatement struct {
-	Effect    string      `json:"Effect"`
-	Action    interface{} `json:"Action"`
-	Resource  interface{} `json:"Resource"`
-	Principal interface{} `json:"Principal"`
+// policyRisk is a grant risk found in a policy document. Key identifies the
+// risk independently of statement order and Sid, so that risks in the before
+// and after documents can be matched.
+type policyRisk struct {
+	Key     string
+	Finding RuleFinding
 }
 
-func analyzePolicyDocument(policyJSON string, address string) []RuleFinding {
+func riskFindings(risks []policyRisk) []RuleFinding {
+	findings := make([]RuleFinding, 0, len(risks))
+	for _, r := range risks {
+		findings = append(findings, r.Finding)
+	}
+	return deduplicateFindings(findings)
+}
+
+// policyRisks finds grant risks in Allow statements. Deny statements only
+// ever remove permissions and are skipped. known holds the accounts that
+// principals may reference without a cross-account finding; with nil, other
+// accounts are reported at LOW.
+func policyRisks(policyJSON string, address string, known map[string]bool) []policyRisk {
 	var doc policyDocument
 	if err := json.Unmarshal([]byte(policyJSON), &doc); err != nil {
 		return nil
 	}
 
-	var findings []RuleFinding
+	var risks []policyRisk
+	var allowed []allowedAction
 
 	for i, stmt := range doc.Statement {
+		if !stmt.isAllow() {
+			continue
+		}
+
 		actions := toStringSlice(stmt.Action)
+		// Escalation applies to identity policies; statements naming a
+		// Principal grant access to the resource the policy is attached to.
+		if stmt.Principal == nil {
+			for _, a := range actions {
+				allowed = append(allowed, allowedAction{Pattern: a, Statement: i})
+			}
+		}
 		resources := toStringSlice(stmt.Resource)
+		actionKey := strings.Join(normalizeActions(actions), ",")
 
 		// Check for wildcard actions.
 		for _, a := range actions {
 			if a == "*" {
-				findings = append(findings, RuleFinding{
-					Severity: SeverityHigh,
-					Tags:     []string{"security"},
-					Title:    fmt.Sprintf("Wildcard Action \"*\" in IAM policy on %s", address),
-					Address:  address,
-					Why: []string{
-						fmt.Sprintf("Statement[%d].Action includes \"*\" (allows all API actions)", i),
-					},
-					Recommendations: []string{
-						"Restrict Action to specific API calls following least-privilege",
-						"Use IAM Access Analyzer to scope down permissions",
+				risks = append(risks, policyRisk{
+					Key: "wildcard-action:" + statementKey(stmt),
+					Finding: RuleFinding{
+						Severity: SeverityHigh,
+						Tags:     []string{"security"},
+						Title:    fmt.Sprintf("Wildcard Action \"*\" in IAM policy on %s", address),
+						Address:  address,
+						Why: []string{
+							fmt.Sprintf("Statement[%d].Action includes \"*\" (allows all API actions)", i),
+						},
+						Recommendations: []string{
+							"Restrict Action to specific API calls following least-privilege",
+							"Use IAM Access Analyzer to scope down permissions",
+						},
 					},
 				})
 			} else if strings.HasSuffix(a, ":*") {
-				findings = append(findings, RuleFinding{
+				risks = append(risks, policyRisk{
+					Key: "service-wildcard:" + strings.ToLower(a),
+					Finding: RuleFinding{
+						Severity: SeverityHigh,
+						Tags:     []string{"security"},
+						Title:    fmt.Sprintf("Wildcard service Action %q in IAM policy on %s", a, address),
+						Address:  address,
+						Why: []string{
+							fmt.Sprintf("Statement[%d].Action includes %q (allows all actions for service)", i, a),
+						},
+						Recommendations: []string{
+							"Restrict Action to specific API calls following least-privilege",
+						},
+					},
+				})
+			}
+		}
+
+		// NotAction in an Allow grants everything except the listed actions.
+		if notActions := toStringSlice(stmt.NotAction); len(notActions) > 0 {
+			risks = append(risks, policyRisk{
+				Key: "not-action:" + strings.Join(normalizeActions(notActions), ","),
+				Finding: RuleFinding{
 					Severity: SeverityHigh,
 					Tags:     []string{"security"},
-					Title:    fmt.Sprintf("Wildcard service Action %q in IAM policy on %s", a, address),
+					Title:    fmt.Sprintf("NotAction in Allow statement in IAM policy on %s", address),
 					Address:  address,
 					Why: []string{
-						fmt.Sprintf("Statement[%d].Action includes %q (allows all actions for service)", i, a),
+						fmt.Sprintf("Statement[%d] allows every action except %s (near-wildcard)", i, strings.Join(notActions, ", ")),
 					},
 					Recommendations: []string{
-						"Restrict Action to specific API calls following least-privilege",
+						"Replace NotAction with an explicit Action list",
+						"If an exception is needed, pair an explicit Allow with a Deny statement",
+					},
+				},
+			})
+		}
+
+		// Check for wildcard resources.
+		for _, res := range resources {
+			if res == "*" {
+				risks = append(risks, policyRisk{
+					Key: "wildcard-resource:" + actionKey,
+					Finding: RuleFinding{
+						Severity: SeverityHigh,
+						Tags:     []string{"security"},
+						Title:    fmt.Sprintf("Wildcard Resource \"*\" in IAM policy on %s", address),
+						Address:  address,
+						Why: []string{
+							fmt.Sprintf("Statement[%d].Resource is \"*\" (applies to all resources)", i),
+						},
+						Recommendations: []string{
+							"Restrict Resource to specific ARNs",
+						},
 					},
 				})
 			}
+		}
 
-			// Check for dangerous actions.
-			lower := strings.ToLower(a)
-			if lower == "iam:passrole" || lower == "sts:assumerole" {
-				findings = append(findings, RuleFinding{
+		// NotResource in an Allow applies to everything except the listed ARNs.
+		if notResources := toStringSlice(stmt.NotResource); len(notResources) > 0 {
+			risks = append(risks, policyRisk{
+				Key: "not-resource:" + strings.Join(sortedCopy(notResources), ","),
+				Finding: RuleFinding{
 					Severity: SeverityHigh,
 					Tags:     []string{"security"},
-					Title:    fmt.Sprintf("Dangerous action %q in IAM policy on %s", a, address),
+					Title:    fmt.Sprintf("NotResource in Allow statement in IAM policy on %s", address),
 					Address:  address,
 					Why: []string{
-						fmt.Sprintf("Statement[%d].Action includes %q (privilege escalation risk)", i, a),
+						fmt.Sprintf("Statement[%d] applies to every resource except %s (near-wildcard)", i, strings.Join(notResources, ", ")),
 					},
 					Recommendations: []string{
-						"Restrict Resource to specific role/user ARNs",
-						"Add conditions to limit scope",
+						"Replace NotResource with an explicit Resource list",
 					},
-				})
-			}
+				},
+			})
 		}
 
-		// Check for wildcard resources.
-		for _, res := range resources {
-			if res == "*" {
-				findings = append(findings, RuleFinding{
+		risks = append(risks, checkPrincipals(stmt, i, address, known)...)
+	}
+
+	risks = append(risks, escalationRisks(allowed, address)...)
+	return risks
+}
+
+// statementKey identifies the grant of an Allow statement by its actions and
+// resources, independently of order, case and Sid, so that a new statement
+// is not mistaken for a pre-existing one with the same kind of risk.
+func statementKey(stmt policyStatement) string {
+	return strings.Join([]string{
+		strings.Join(normalizeActions(toStringSlice(stmt.Action)), ","),
+		strings.Join(normalizeActions(toStringSlice(stmt.NotAction)), ","),
+		strings.Join(sortedCopy(toStringSlice(stmt.Resource)), ","),
+		strings.Join(sortedCopy(toStringSlice(stmt.NotResource)), ","),
+	}, "|")
+}
+
+// checkPrincipals flags public principals without a restricting condition
+// and principals from accounts outside known.
+func checkPrincipals(stmt policyStatement, index int, address string, known map[string]bool) []policyRisk {
+	var risks []policyRisk
+	for _, p := range stmt.awsPrincipals() {
+		if p == "*" {
+			if stmt.hasRestrictingCondition() {
+				continue
+			}
+			risks = append(risks, policyRisk{
+				Key: "public-principal:" + statementKey(stmt),
+				Finding: RuleFinding{
 					Severity: SeverityHigh,
 					Tags:     []string{"security"},
-					Title:    fmt.Sprintf("Wildcard Resource \"*\" in IAM policy on %s", address),
+					Title:    fmt.Sprintf("Public principal \"*\" in policy on %s", address),
 					Address:  address,
 					Why: []string{
-						fmt.Sprintf("Statement[%d].Resource is \"*\" (applies to all resources)", i),
+						fmt.Sprintf("Statement[%d].Principal allows any AWS principal, with no aws:SourceAccount, aws:PrincipalOrgID or similar condition", index),
 					},
 					Recommendations: []string{
-						"Restrict Resource to specific ARNs",
+						"Restrict Principal to specific account or role ARNs",
+						"Or add an aws:PrincipalOrgID / aws:SourceAccount condition",
 					},
-				})
-			}
+				},
+			})
+			continue
 		}
-	}
 
-	return deduplicateFindings(findings)
+		acct := accountFromPrincipal(p)
+		if acct == "" || known[acct] {
+			continue
+		}
+		sev, why := SeverityMedium, "Account owns no resource in the plan and is not listed in rules.trusted_accounts"
+		if known == nil {
+			sev, why = SeverityLow, unknownOwnerWhy
+		}
+		risks = append(risks, policyRisk{
+			Key: "cross-account:" + acct,
+			Finding: RuleFinding{
+				Severity: sev,
+				Tags:     []string{"security"},
+				Title:    fmt.Sprintf("Cross-account principal %s in policy on %s", acct, address),
+				Address:  address,
+				Why: []string{
+					fmt.Sprintf("Statement[%d].Principal grants access to %s in account %s", index, p, acct),
+					why,
+				},
+				Recommendations: []string{
+					"Confirm the external account is expected to have this access",
+					"Add the account to rules.trusted_accounts in the tf-why config if it is trusted",
+				},
+			},
+		})
+	}
+	return risks
 }
 
 func checkPublicAccessBlock(afterData map[string]interface{}, rc plan.ResourceChange) []RuleFinding {
@@ -0,0 +1,231 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxGrantDeltaLines caps the Why lines of a permission-delta finding.
+const maxGrantDeltaLines = 15
+
+// grant is one normalized (action, resource) permission of a statement.
+// Scope holds everything else that qualifies the permission: effect,
+// principals, conditions and the Not* forms. Sid and statement order are
+// ignored so that cosmetic rewrites produce no delta.
+type grant struct {
+	Scope    string
+	Action   string
+	Resource string
+}
+
+// policyGrants expands a policy document into its normalized grants.
+// It returns ok=false when the document cannot be parsed.
+func policyGrants(policyJSON string) (map[grant]bool, bool) {
+	var doc policyDocument
+	if err := json.Unmarshal([]byte(policyJSON), &doc); err != nil {
+		return nil, false
+	}
+
+	grants := make(map[grant]bool)
+	for _, stmt := range doc.Statement {
+		scope := statementScope(stmt)
+
+		actions := normalizeActions(toStringSlice(stmt.Action))
+		if notActions := toStringSlice(stmt.NotAction); len(notActions) > 0 {
+			actions = []string{"NOT(" + strings.Join(normalizeActions(notActions), ",") + ")"}
+		}
+		resources := sortedCopy(toStringSlice(stmt.Resource))
+		if notResources := toStringSlice(stmt.NotResource); len(notResources) > 0 {
+			resources = []string{"NOT(" + strings.Join(sortedCopy(notResources), ",") + ")"}
+		}
+		if len(resources) == 0 {
+			// Resource-less statements (e.g. trust policies) apply to the
+			// resource the policy is attached to.
+			resources = []string{"(this resource)"}
+		}
+
+		for _, a := range actions {
+			for _, r := range resources {
+				grants[grant{Scope: scope, Action: a, Resource: r}] = true
+			}
+		}
+	}
+	return grants, true
+}
+
+// statementScope renders the non-action, non-resource parts of a statement
+// canonically.
+func statementScope(stmt policyStatement) string {
+	parts := []string{titleCase(stmt.Effect)}
+
+	if principals := normalizePrincipals(stmt.Principal); principals != "" {
+		parts = append(parts, "for "+principals)
+	}
+	if len(stmt.Condition) > 0 {
+		// encoding/json sorts map keys, which makes this canonical.
+		cond, _ := json.Marshal(normalizeCondition(stmt.Condition))
+		parts = append(parts, "when "+string(cond))
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizePrincipals renders a Principal element as sorted "Type:value"
+// entries. Bare account IDs are rewritten to their root ARN form.
+func normalizePrincipals(p interface{}) string {
+	var entries []string
+	switch v := p.(type) {
+	case string:
+		entries = append(entries, "AWS:"+v)
+	case map[string]interface{}:
+		for typ, vals := range v {
+			for _, val := range toStringSlice(vals) {
+				if typ == "AWS" && isAccountID(val) {
+					val = "arn:aws:iam::" + val + ":root"
+				}
+				entries = append(entries, typ+":"+val)
+			}
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// normalizeCondition lowercases operator and key names (both are case
+// insensitive in IAM) and turns single values into sorted lists.
+func normalizeCondition(cond map[string]map[string]interface{}) map[string]map[string][]string {
+	out := make(map[string]map[string][]string, len(cond))
+	for op, kv := range cond {
+		m := make(map[string][]string, len(kv))
+		for k, v := range kv {
+			m[strings.ToLower(k)] = sortedCopy(toStringSlice(v))
+		}
+		out[strings.ToLower(op)] = m
+	}
+	return out
+}
+
+// normalizeActions lowercases (actions are case insensitive), sorts and
+// deduplicates.
+func normalizeActions(actions []string) []string {
+	seen := make(map[string]bool, len(actions))
+	result := make([]string, 0, len(actions))
+	for _, a := range actions {
+		l := strings.ToLower(a)
+		if !seen[l] {
+			seen[l] = true
+			result = append(result, l)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sortedCopy(in []string) []string {
+	out := append([]string(nil), in...)
+	sort.Strings(out)
+	return out
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	l := strings.ToLower(s)
+	return strings.ToUpper(l[:1]) + l[1:]
+}
+
+// grantDeltaLines describes added and removed grants, grouped by scope and
+// resource, as "+"/"-" lines.
+func grantDeltaLines(before, after map[grant]bool) []string {
+	added := groupGrants(after, before)
+	removed := groupGrants(before, after)
+
+	var lines []string
+	for _, g := range added {
+		lines = append(lines, "+ "+g)
+	}
+	for _, g := range removed {
+		lines = append(lines, "- "+g)
+	}
+	if len(lines) > maxGrantDeltaLines {
+		more := len(lines) - maxGrantDeltaLines
+		lines = append(lines[:maxGrantDeltaLines], fmt.Sprintf("... and %d more change(s)", more))
+	}
+	return lines
+}
+
+// groupGrants returns the grants in a that are not in b, rendered as
+// "<scope> <actions> on <resource>" and sorted.
+func groupGrants(a, b map[grant]bool) []string {
+	type key struct{ scope, resource string }
+	grouped := make(map[key][]string)
+	for g := range a {
+		if b[g] {
+			continue
+		}
+		k := key{g.Scope, g.Resource}
+		grouped[k] = append(grouped[k], g.Action)
+	}
+
+	lines := make([]string, 0, len(grouped))
+	for k, actions := range grouped {
+		sort.Strings(actions)
+		lines = append(lines, fmt.Sprintf("%s %s on %s", k.scope, strings.Join(actions, ", "), k.resource))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// policyDeltaFinding reports the permissions granted or revoked between two
+// policy documents. It returns nil when the documents are semantically equal
+// or either cannot be parsed.
+func policyDeltaFinding(beforeJSON, afterJSON, address string) []RuleFinding {
+	before, okBefore := policyGrants(beforeJSON)
+	after, okAfter := policyGrants(afterJSON)
+	if !okBefore || !okAfter {
+		return nil
+	}
+
+	lines := grantDeltaLines(before, after)
+	if len(lines) == 0 {
+		return nil
+	}
+	return []RuleFinding{{
+		Severity: SeverityLow,
+		Tags:     []string{"security"},
+		Title:    fmt.Sprintf("IAM policy permissions changed on %s", address),
+		Address:  address,
+		Why:      lines,
+		Recommendations: []string{
+			"Review added permissions (+) for least-privilege",
+			"Confirm removed permissions (-) are no longer needed by any workload",
+		},
+	}}
+}
+
+// newRisks drops risks already present in the before document. When
+// reportPreexisting is set they are kept at LOW severity with an annotation
+// instead.
+func newRisks(afterRisks, beforeRisks []policyRisk, reportPreexisting bool) []policyRisk {
+	prior := make(map[string]bool, len(beforeRisks))
+	for _, r := range beforeRisks {
+		prior[r.Key] = true
+	}
+
+	var result []policyRisk
+	for _, r := range afterRisks {
+		if !prior[r.Key] {
+			result = append(result, r)
+			continue
+		}
+		if reportPreexisting {
+			r.Finding.Severity = SeverityLow
+			r.Finding.Why = append(append([]string(nil), r.Finding.Why...),
+				"Pre-existing: already granted before this change")
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// escalationPath is a known IAM privilege-escalation primitive: a set of
+// actions that together let a principal obtain more permissions than it was
+// granted.
+type escalationPath struct {
+	ID       string
+	Actions  []string // all must be allowed for the path to apply
+	Describe string   // how the escalation works
+}
+
+// escalationCatalog lists escalation primitives, after Rhino Security Labs'
+// AWS privilege escalation research. Single-action paths come first.
+var escalationCatalog = []escalationPath{
+	{"pass-role", []string{"iam:PassRole"},
+		"can hand roles to AWS services and act with those roles' permissions"},
+	{"assume-role", []string{"sts:AssumeRole"},
+		"can assume other roles and act with their permissions"},
+	{"create-policy-version", []string{"iam:CreatePolicyVersion"},
+		"can publish a new default version of a managed policy granting any permission"},
+	{"set-default-policy-version", []string{"iam:SetDefaultPolicyVersion"},
+		"can switch a managed policy to an older, more permissive version"},
+	{"attach-user-policy", []string{"iam:AttachUserPolicy"},
+		"can attach any managed policy (e.g. AdministratorAccess) to a user"},
+	{"attach-group-policy", []string{"iam:AttachGroupPolicy"},
+		"can attach any managed policy (e.g. AdministratorAccess) to a group"},
+	{"attach-role-policy", []string{"iam:AttachRolePolicy"},
+		"can attach any managed policy (e.g. AdministratorAccess) to a role"},
+	{"put-user-policy", []string{"iam:PutUserPolicy"},
+		"can write an inline policy granting any permission to a user"},
+	{"put-group-policy", []string{"iam:PutGroupPolicy"},
+		"can write an inline policy granting any permission to a group"},
+	{"put-role-policy", []string{"iam:PutRolePolicy"},
+		"can write an inline policy granting any permission to a role"},
+	{"add-user-to-group", []string{"iam:AddUserToGroup"},
+		"can add a user to a more privileged group"},
+	{"update-assume-role-policy", []string{"iam:UpdateAssumeRolePolicy"},
+		"can rewrite a role's trust policy to allow itself to assume the role"},
+	{"create-access-key", []string{"iam:CreateAccessKey"},
+		"can create access keys for other users and act as them"},
+	{"create-login-profile", []string{"iam:CreateLoginProfile"},
+		"can set a console password for another user and sign in as them"},
+	{"update-login-profile", []string{"iam:UpdateLoginProfile"},
+		"can change another user's console password and sign in as them"},
+	{"ssm-send-command", []string{"ssm:SendCommand"},
+		"can run commands on managed instances and use their instance roles"},
+	{"ssm-start-session", []string{"ssm:StartSession"},
+		"can open a shell on managed instances and use their instance roles"},
+	{"lambda-create-invoke", []string{"iam:PassRole", "lambda:CreateFunction", "lambda:InvokeFunction"},
+		"can create a Lambda function running as a more privileged role and invoke it"},
+	{"lambda-update-code", []string{"iam:PassRole", "lambda:UpdateFunctionCode"},
+		"can replace an existing function's code and run it with the function's role"},
+	{"ec2-run-instances", []string{"iam:PassRole", "ec2:RunInstances"},
+		"can launch an instance with a privileged instance profile and read its credentials"},
+	{"cloudformation-create-stack", []string{"iam:PassRole", "cloudformation:CreateStack"},
+		"can create a stack that provisions resources as a more privileged role"},
+	{"glue-dev-endpoint", []string{"iam:PassRole", "glue:CreateDevEndpoint"},
+		"can create a Glue dev endpoint with a privileged role and log in to it"},
+	{"ecs-run-task", []string{"iam:PassRole", "ecs:RegisterTaskDefinition", "ecs:RunTask"},
+		"can run a task with a privileged task role and read its credentials"},
+}
+
+// allowedAction is an action pattern granted by an Allow statement.
+type allowedAction struct {
+	Pattern   string
+	Statement int
+}
+
+// isReportedWildcard reports whether pattern is "*" or "service:*", which
+// already have their own findings.
+func isReportedWildcard(pattern string) bool {
+	return pattern == "*" || strings.HasSuffix(pattern, ":*")
+}
+
+// escalationRisks matches the actions allowed across all statements of a
+// policy against escalationCatalog. Action patterns such as "iam:Put*" are
+// expanded. Paths covered only by "*" or "service:*" are skipped since those
+// grants are already reported.
+func escalationRisks(allowed []allowedAction, address string) []policyRisk {
+	var risks []policyRisk
+	for _, path := range escalationCatalog {
+		var why []string
+		covered, explicit := true, false
+		for _, action := range path.Actions {
+			grant, ok := findGrant(allowed, action)
+			if !ok {
+				covered = false
+				break
+			}
+			if !isReportedWildcard(grant.Pattern) {
+				explicit = true
+			}
+			if strings.EqualFold(grant.Pattern, action) {
+				why = append(why, fmt.Sprintf("Statement[%d] allows %s", grant.Statement, action))
+			} else {
+				why = append(why, fmt.Sprintf("Statement[%d] allows %s (via %q)", grant.Statement, action, grant.Pattern))
+			}
+		}
+		if !covered || !explicit {
+			continue
+		}
+
+		why = append(why, "Escalation path: holder "+path.Describe)
+		risks = append(risks, policyRisk{
+			Key: "escalation:" + path.ID,
+			Finding: RuleFinding{
+				Severity: SeverityHigh,
+				Tags:     []string{"security"},
+				Title:    fmt.Sprintf("Privilege escalation via %s in IAM policy on %s", strings.Join(path.Actions, " + "), address),
+				Address:  address,
+				Why:      why,
+				Recommendations: []string{
+					"Restrict Resource to specific role/user/policy ARNs",
+					"Add conditions (e.g. iam:PassedToService, aws:ResourceTag) to limit scope",
+				},
+			},
+		})
+	}
+	return risks
+}
+
+// findGrant returns the allowed pattern covering action, preferring one that
+// is not a reported wildcard.
+func findGrant(allowed []allowedAction, action string) (allowedAction, bool) {
+	var fallback allowedAction
+	found := false
+	for _, a := range allowed {
+		if !util.MatchGlob(strings.ToLower(a.Pattern), strings.ToLower(action)) {
+			continue
+		}
+		if !isReportedWildcard(a.Pattern) {
+			return a, true
+		}
+		if !found {
+			fallback, found = a, true
+		}
+	}
+	return fallback, found
+}
@@ -0,0 +1,267 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+// policyAttributes maps resource types to the attributes holding policy
+// documents as JSON strings. A "*" segment iterates a nested block list, e.g.
+// "inline_policy.*.policy".
+var policyAttributes = map[string][]string{
+	"aws_iam_policy":                                 {"policy"},
+	"aws_iam_role_policy":                            {"policy"},
+	"aws_iam_user_policy":                            {"policy"},
+	"aws_iam_group_policy":                           {"policy"},
+	"aws_iam_role":                                   {"inline_policy.*.policy"}, // assume_role_policy: see TrustPolicyRule
+	"aws_ssoadmin_permission_set_inline_policy":      {"inline_policy"},
+	"aws_s3_bucket":                                  {"policy"},
+	"aws_s3_bucket_policy":                           {"policy"},
+	"aws_s3_access_point":                            {"policy"},
+	"aws_sqs_queue":                                  {"policy"},
+	"aws_sqs_queue_policy":                           {"policy"},
+	"aws_sns_topic":                                  {"policy"},
+	"aws_sns_topic_policy":                           {"policy"},
+	"aws_kms_key":                                    {"policy"},
+	"aws_ecr_repository_policy":                      {"policy"},
+	"aws_ecr_registry_policy":                        {"policy"},
+	"aws_secretsmanager_secret":                      {"policy"},
+	"aws_secretsmanager_secret_policy":               {"policy"},
+	"aws_opensearch_domain":                          {"access_policies"},
+	"aws_opensearch_domain_policy":                   {"access_policies"},
+	"aws_elasticsearch_domain":                       {"access_policies"},
+	"aws_elasticsearch_domain_policy":                {"access_policies"},
+	"aws_glacier_vault":                              {"access_policy"},
+	"aws_efs_file_system_policy":                     {"policy"},
+	"aws_backup_vault_policy":                        {"policy"},
+	"aws_api_gateway_rest_api":                       {"policy"},
+	"aws_api_gateway_rest_api_policy":                {"policy"},
+	"aws_cloudwatch_log_resource_policy":             {"policy_document"},
+	"aws_codeartifact_domain_permissions_policy":     {"policy_document"},
+	"aws_codeartifact_repository_permissions_policy": {"policy_document"},
+}
+
+// policySource is one policy document found on a resource.
+type policySource struct {
+	Path string // attribute path, e.g. `inline_policy["s3"].policy`
+	JSON string
+}
+
+// carriesPolicy reports whether rc may hold policy documents.
+func carriesPolicy(rc plan.ResourceChange) bool {
+	if rc.Mode == "data" {
+		return rc.Type == "aws_iam_policy_document"
+	}
+	return rc.Type == "aws_lambda_permission" || policyAttributes[rc.Type] != nil
+}
+
+// policySources returns the policy documents in a resource's state. Lambda
+// permissions and aws_iam_policy_document data sources are converted to an
+// equivalent single-statement or multi-statement document.
+func policySources(rc plan.ResourceChange, data map[string]interface{}) []policySource {
+	if data == nil {
+		return nil
+	}
+	if rc.Mode == "data" {
+		if rc.Type == "aws_iam_policy_document" {
+			return policyDocumentSources(data)
+		}
+		return nil
+	}
+	if rc.Type == "aws_lambda_permission" {
+		return lambdaPermissionSources(data)
+	}
+
+	var sources []policySource
+	for _, path := range policyAttributes[rc.Type] {
+		sources = append(sources, resolvePolicyPath(data, strings.Split(path, "."), "")...)
+	}
+	return sources
+}
+
+// labelsPolicyPath reports whether findings for resourceType should name the
+// attribute they come from, because it may hold more than one document.
+func labelsPolicyPath(resourceType string) bool {
+	paths := policyAttributes[resourceType]
+	return len(paths) > 1 || (len(paths) == 1 && strings.Contains(paths[0], "*"))
+}
+
+func resolvePolicyPath(v interface{}, segments []string, prefix string) []policySource {
+	if len(segments) == 0 {
+		if s, ok := v.(string); ok && s != "" {
+			return []policySource{{Path: prefix, JSON: s}}
+		}
+		return nil
+	}
+
+	seg, rest := segments[0], segments[1:]
+	if seg == "*" {
+		list, _ := v.([]interface{})
+		var sources []policySource
+		for i, elem := range list {
+			label := fmt.Sprintf("%s[%d]", prefix, i)
+			if m, ok := elem.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok && name != "" {
+					label = fmt.Sprintf("%s[%q]", prefix, name)
+				}
+			}
+			sources = append(sources, resolvePolicyPath(elem, rest, label)...)
+		}
+		return sources
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	label := seg
+	if prefix != "" {
+		label = prefix + "." + seg
+	}
+	return resolvePolicyPath(m[seg], rest, label)
+}
+
+// lambdaPermissionSources renders an aws_lambda_permission as the statement
+// it adds to the function's resource-based policy.
+func lambdaPermissionSources(data map[string]interface{}) []policySource {
+	action, _ := data["action"].(string)
+	principal, _ := data["principal"].(string)
+	if action == "" || principal == "" {
+		return nil
+	}
+
+	stmt := map[string]interface{}{
+		"Effect": "Allow",
+		"Action": action,
+	}
+	switch {
+	case principal == "*":
+		stmt["Principal"] = "*"
+	case isAccountID(principal) || strings.HasPrefix(principal, "arn:"):
+		stmt["Principal"] = map[string]interface{}{"AWS": principal}
+	default:
+		stmt["Principal"] = map[string]interface{}{"Service": principal}
+	}
+	if fn, ok := data["function_name"].(string); ok && fn != "" {
+		stmt["Resource"] = fn
+	}
+
+	cond := map[string]map[string]interface{}{}
+	if v, ok := data["source_arn"].(string); ok && v != "" {
+		cond["ArnLike"] = map[string]interface{}{"aws:SourceArn": v}
+	}
+	if v, ok := data["source_account"].(string); ok && v != "" {
+		addCondition(cond, "StringEquals", "aws:SourceAccount", v)
+	}
+	if v, ok := data["principal_org_id"].(string); ok && v != "" {
+		addCondition(cond, "StringEquals", "aws:PrincipalOrgID", v)
+	}
+	if len(cond) > 0 {
+		stmt["Condition"] = cond
+	}
+
+	return []policySource{{Path: "permission", JSON: marshalPolicy([]interface{}{stmt})}}
+}
+
+// policyDocumentSources reads an aws_iam_policy_document data source. The
+// rendered "json" attribute is used when known; otherwise, as for a deferred
+// read, the statement blocks from configuration are converted.
+func policyDocumentSources(data map[string]interface{}) []policySource {
+	if s, ok := data["json"].(string); ok && s != "" {
+		return []policySource{{Path: "json", JSON: s}}
+	}
+
+	var sources []policySource
+	for _, key := range []string{"source_policy_documents", "override_policy_documents"} {
+		for i, doc := range toStringSlice(data[key]) {
+			if doc != "" {
+				sources = append(sources, policySource{Path: fmt.Sprintf("%s[%d]", key, i), JSON: doc})
+			}
+		}
+	}
+
+	blocks, _ := data["statement"].([]interface{})
+	var stmts []interface{}
+	for _, b := range blocks {
+		m, ok := b.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stmts = append(stmts, policyDocumentStatement(m))
+	}
+	if len(stmts) > 0 {
+		sources = append(sources, policySource{Path: "statement", JSON: marshalPolicy(stmts)})
+	}
+	return sources
+}
+
+func policyDocumentStatement(block map[string]interface{}) map[string]interface{} {
+	stmt := map[string]interface{}{"Effect": "Allow"}
+	if e, ok := block["effect"].(string); ok && e != "" {
+		stmt["Effect"] = e
+	}
+	for attr, key := range map[string]string{
+		"actions":       "Action",
+		"not_actions":   "NotAction",
+		"resources":     "Resource",
+		"not_resources": "NotResource",
+	} {
+		if vals := toStringSlice(block[attr]); len(vals) > 0 {
+			stmt[key] = vals
+		}
+	}
+
+	if principals, ok := block["principals"].([]interface{}); ok && len(principals) > 0 {
+		p := map[string][]string{}
+		for _, raw := range principals {
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			typ, _ := m["type"].(string)
+			ids := toStringSlice(m["identifiers"])
+			if typ == "*" {
+				// principals { type = "*" identifiers = ["*"] } is "Principal": "*".
+				stmt["Principal"] = "*"
+				continue
+			}
+			p[typ] = append(p[typ], ids...)
+		}
+		if _, set := stmt["Principal"]; !set && len(p) > 0 {
+			stmt["Principal"] = p
+		}
+	}
+
+	if conditions, ok := block["condition"].([]interface{}); ok && len(conditions) > 0 {
+		cond := map[string]map[string]interface{}{}
+		for _, raw := range conditions {
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			test, _ := m["test"].(string)
+			variable, _ := m["variable"].(string)
+			addCondition(cond, test, variable, toStringSlice(m["values"]))
+		}
+		stmt["Condition"] = cond
+	}
+	return stmt
+}
+
+func addCondition(cond map[string]map[string]interface{}, op, key string, value interface{}) {
+	if cond[op] == nil {
+		cond[op] = map[string]interface{}{}
+	}
+	cond[op][key] = value
+}
+
+func marshalPolicy(statements []interface{}) string {
+	out, _ := json.Marshal(map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	})
+	return string(out)
+}
@@ -0,0 +1,316 @@
+package rules
+
+import "testing"
+
+func TestIAMWildcardAction(t *testing.T) {
+	findings := evaluateAll(t, "iam_wildcard.json")
+	if len(findings) == 0 {
+		t.Fatal("expected findings for wildcard IAM policy")
+	}
+	var hasWildcardAction, hasWildcardResource bool
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && containsTag(f.Tags, "security") {
+			for _, w := range f.Why {
+				if contains(w, "Action") && contains(w, "\"*\"") {
+					hasWildcardAction = true
+				}
+				if contains(w, "Resource") && contains(w, "\"*\"") {
+					hasWildcardResource = true
+				}
+			}
+		}
+	}
+	if !hasWildcardAction {
+		t.Error("expected finding for wildcard Action")
+	}
+	if !hasWildcardResource {
+		t.Error("expected finding for wildcard Resource")
+	}
+}
+
+func TestIAMServiceWildcard(t *testing.T) {
+	findings := evaluateAll(t, "iam_service_wildcard.json")
+	found := false
+	for _, f := range findings {
+		for _, w := range f.Why {
+			if contains(w, "s3:*") {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("expected finding for s3:* wildcard action")
+	}
+}
+
+func TestIAMPassRole(t *testing.T) {
+	findings := evaluateAll(t, "iam_passrole.json")
+	var hasPassRole, hasAssumeRole bool
+	for _, f := range findings {
+		for _, w := range f.Why {
+			if contains(w, "iam:PassRole") {
+				hasPassRole = true
+			}
+			if contains(w, "sts:AssumeRole") {
+				hasAssumeRole = true
+			}
+		}
+	}
+	if !hasPassRole {
+		t.Error("expected finding for iam:PassRole")
+	}
+	if !hasAssumeRole {
+		t.Error("expected finding for sts:AssumeRole")
+	}
+}
+
+func TestIAMDenyIgnored(t *testing.T) {
+	findings := evaluateAll(t, "iam_deny_wildcard.json")
+	if len(findings) != 0 {
+		t.Errorf("expected no findings for Deny \"*\" guardrail, got %d: %v", len(findings), findings)
+	}
+}
+
+func TestIAMNotActionNotResource(t *testing.T) {
+	findings := evaluateAll(t, "iam_notaction.json")
+	var hasNotAction, hasNotResource bool
+	for _, f := range findings {
+		if f.Severity != SeverityHigh {
+			continue
+		}
+		if contains(f.Title, "NotAction") && contains(f.Why[0], "iam:*") {
+			hasNotAction = true
+		}
+		if contains(f.Title, "NotResource") && contains(f.Why[0], "arn:aws:s3:::secrets/*") {
+			hasNotResource = true
+		}
+	}
+	if !hasNotAction {
+		t.Error("expected HIGH finding for Allow with NotAction")
+	}
+	if !hasNotResource {
+		t.Error("expected HIGH finding for Allow with NotResource")
+	}
+}
+
+func TestIAMPublicPrincipal(t *testing.T) {
+	findings := evaluateAll(t, "iam_public_principal.json")
+	var public int
+	for _, f := range findings {
+		if contains(f.Title, "Public principal") {
+			public++
+			if !contains(f.Why[0], "Statement[0]") {
+				t.Errorf("expected only the unconditioned statement to be flagged, got %v", f.Why)
+			}
+		}
+	}
+	if public != 1 {
+		t.Errorf("expected 1 public principal finding (org-restricted statement excluded), got %d", public)
+	}
+}
+
+func TestIAMCrossAccountPrincipal(t *testing.T) {
+	p := loadTestPlan(t, "iam_cross_account.json")
+	if got := PlanAccounts(p); len(got) != 2 || got[0] != "111111111111" || got[1] != "333333333333" {
+		t.Fatalf("expected the caller identity and queue accounts, got %v", got)
+	}
+	bucket := findChange(t, p, "aws_s3_bucket_policy.shared")
+
+	// The plan's own account is known; only the other account is reported.
+	rule := &IAMPolicyRule{OwnerAccounts: PlanAccounts(p)}
+	checkFindings(t, rule.Evaluate(bucket), wantFinding{"999999999999", SeverityMedium, ""})
+
+	trusted := &IAMPolicyRule{TrustedAccounts: []string{"999999999999"}, OwnerAccounts: PlanAccounts(p)}
+	if findings := trusted.Evaluate(bucket); len(findings) != 0 {
+		t.Errorf("expected no findings for a trusted account, got %v", findings)
+	}
+}
+
+func TestIAMCrossAccountTrustedWithoutOwner(t *testing.T) {
+	// A bucket policy has no ARN and the plan names no account; the
+	// configured allowlist is still enforced.
+	rule := &IAMPolicyRule{TrustedAccounts: []string{"111111111111"}}
+	checkFindings(t, evaluateAddress(t, rule, "iam_cross_account.json", "aws_s3_bucket_policy.shared"),
+		wantFinding{"Cross-account principal 999999999999", SeverityMedium, "not listed in rules.trusted_accounts"})
+}
+
+func TestIAMCrossAccountUnknownOwner(t *testing.T) {
+	// With no account known at all, the bucket's own account cannot be told
+	// apart from a foreign one: both are reported, at LOW.
+	checkFindings(t, evaluateAddress(t, &IAMPolicyRule{}, "iam_cross_account.json", "aws_s3_bucket_policy.shared"),
+		wantFinding{"Cross-account principal 111111111111", SeverityLow, "may be the resource's own account"},
+		wantFinding{"Cross-account principal 999999999999", SeverityLow, "may be the resource's own account"})
+}
+
+func TestIAMCrossAccountQueueOwner(t *testing.T) {
+	// The queue URL names the queue's account.
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_cross_account.json", "aws_sqs_queue_policy.jobs")
+	if len(findings) != 0 {
+		t.Errorf("expected the queue's own account to be known, got %v", findings)
+	}
+}
+
+func TestIAMEscalationCatalog(t *testing.T) {
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_escalation.json", "aws_iam_policy.ci")
+	titles := map[string]RuleFinding{}
+	for _, f := range findings {
+		titles[f.Title] = f
+	}
+	putRole, ok := titles["Privilege escalation via iam:PutRolePolicy in IAM policy on aws_iam_policy.ci"]
+	if !ok {
+		t.Fatalf("expected iam:Put* to match iam:PutRolePolicy, got %v", findings)
+	}
+	if !contains(putRole.Why[0], `via "iam:Put*"`) {
+		t.Errorf("expected why to name the matching pattern, got %v", putRole.Why)
+	}
+	combo, ok := titles["Privilege escalation via iam:PassRole + lambda:UpdateFunctionCode in IAM policy on aws_iam_policy.ci"]
+	if !ok {
+		t.Fatalf("expected PassRole + UpdateFunctionCode combination across statements, got %v", findings)
+	}
+	if combo.Severity != SeverityHigh || !contains(combo.Why[len(combo.Why)-1], "Escalation path") {
+		t.Errorf("expected HIGH finding explaining the path, got %s %v", severityName(combo.Severity), combo.Why)
+	}
+}
+
+func TestIAMEscalationIncompleteCombination(t *testing.T) {
+	// UpdateFunctionCode without PassRole is not an escalation path.
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_escalation.json", "aws_iam_policy.readonly")
+	if len(findings) != 0 {
+		t.Errorf("expected no findings for incomplete combination, got %v", findings)
+	}
+}
+
+func TestIAMEscalationSkipsReportedWildcards(t *testing.T) {
+	for _, f := range evaluateAll(t, "iam_wildcard.json") {
+		if contains(f.Title, "Privilege escalation") {
+			t.Errorf("expected \"*\" to be reported only as a wildcard, got %q", f.Title)
+		}
+	}
+}
+
+// hasHighFinding reports whether findings hold a HIGH finding whose title
+// contains title.
+func hasHighFinding(findings []RuleFinding, title string) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && contains(f.Title, title) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIAMQueuePolicy(t *testing.T) {
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_resource_policies.json", "aws_sqs_queue_policy.events")
+	if !hasHighFinding(findings, "Public principal") {
+		t.Errorf("expected HIGH public principal finding, got %v", findings)
+	}
+}
+
+func TestIAMRoleInlinePolicy(t *testing.T) {
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_resource_policies.json", "aws_iam_role.app")
+	if !hasHighFinding(findings, `Wildcard Action "*" in IAM policy on aws_iam_role.app (inline_policy["admin"].policy)`) {
+		t.Errorf("expected HIGH wildcard finding naming the inline policy, got %v", findings)
+	}
+}
+
+func TestIAMLambdaPermission(t *testing.T) {
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_resource_policies.json", "aws_lambda_permission.public")
+	if !hasHighFinding(findings, "Public principal") {
+		t.Errorf("expected HIGH public principal finding, got %v", findings)
+	}
+
+	// A service principal scoped to a source ARN is not public.
+	findings = evaluateAddress(t, &IAMPolicyRule{}, "iam_resource_policies.json", "aws_lambda_permission.s3")
+	if len(findings) != 0 {
+		t.Errorf("expected no findings for a scoped service principal, got %v", findings)
+	}
+}
+
+func TestIAMPolicyDocumentDataSource(t *testing.T) {
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_resource_policies.json", "data.aws_iam_policy_document.deploy")
+	if !hasHighFinding(findings, `Wildcard service Action "s3:*"`) {
+		t.Errorf("expected HIGH s3:* finding, got %v", findings)
+	}
+}
+
+func TestIAMOpenSearchAccessPolicy(t *testing.T) {
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_resource_policies.json", "aws_opensearch_domain.search")
+	if !hasHighFinding(findings, "Public principal") {
+		t.Errorf("expected HIGH public principal finding, got %v", findings)
+	}
+}
+
+func TestIAMPolicyUpdatePreexistingWildcard(t *testing.T) {
+	// An unrelated edit to a policy that already grants "*" reports only the delta.
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_policy_update.json", "aws_iam_policy.admin")
+	if len(findings) != 1 || findings[0].Severity != SeverityLow || !contains(findings[0].Title, "permissions changed") {
+		t.Fatalf("expected only a LOW permissions-changed finding for pre-existing wildcard, got %v", findings)
+	}
+	if len(findings[0].Why) != 1 || !contains(findings[0].Why[0], "+ Allow logs:putlogevents") {
+		t.Errorf("expected added logs:PutLogEvents grant, got %v", findings[0].Why)
+	}
+}
+
+func TestIAMPolicyUpdateNewWildcard(t *testing.T) {
+	// A newly added service wildcard is reported alongside the delta.
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_policy_update.json", "aws_iam_role_policy.app")
+	var high, delta bool
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && contains(f.Title, "dynamodb:*") {
+			high = true
+		}
+		if contains(f.Title, "permissions changed") {
+			delta = true
+		}
+	}
+	if !high || !delta {
+		t.Errorf("expected HIGH dynamodb:* finding and permissions delta, got %v", findings)
+	}
+}
+
+func TestIAMPolicyUpdateCosmetic(t *testing.T) {
+	// Reordered actions, renamed Sid and string-vs-list are cosmetic.
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_policy_update.json", "aws_iam_policy.reader")
+	if len(findings) != 0 {
+		t.Errorf("expected no findings for cosmetic policy rewrite, got %v", findings)
+	}
+}
+
+func TestIAMPolicyUpdateNewPublicStatement(t *testing.T) {
+	// A public read statement already existed; a new public write
+	// statement is new exposure, not a pre-existing risk.
+	findings := evaluateAddress(t, &IAMPolicyRule{}, "iam_policy_update.json", "aws_s3_bucket_policy.site")
+	var public int
+	for _, f := range findings {
+		if contains(f.Title, "Public principal") {
+			public++
+			if f.Severity != SeverityHigh || !contains(f.Why[0], "Statement[1]") {
+				t.Errorf("expected HIGH finding for Statement[1], got %s %v", severityName(f.Severity), f.Why)
+			}
+		}
+	}
+	if public != 1 {
+		t.Errorf("expected 1 public principal finding for the new statement, got %v", findings)
+	}
+}
+
+func TestIAMPolicyUpdateReportPreexisting(t *testing.T) {
+	rule := &IAMPolicyRule{ReportPreexisting: true}
+	findings := evaluateAddress(t, rule, "iam_policy_update.json", "aws_iam_policy.admin")
+
+	found := false
+	for _, f := range findings {
+		if contains(f.Title, "Wildcard") {
+			found = true
+			if f.Severity != SeverityLow {
+				t.Errorf("expected pre-existing risk downgraded to LOW, got %s", severityName(f.Severity))
+			}
+			if !contains(f.Why[len(f.Why)-1], "Pre-existing") {
+				t.Errorf("expected pre-existing note in why, got %v", f.Why)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected pre-existing wildcard finding, got %v", findings)
+	}
+}
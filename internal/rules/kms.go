@@ -1,7 +1,9 @@
 package rules
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/djeeteg007/tf-why/internal/plan"
 	"github.com/djeeteg007/tf-why/internal/util"
@@ -15,6 +17,8 @@ var kmsTypes = map[string]bool{
 // KMSRule detects risky KMS key/alias changes.
 type KMSRule struct{}
 
+func (r *KMSRule) ID() string { return "kms" }
+
 func (r *KMSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 	if !kmsTypes[rc.Type] {
 		return nil
@@ -54,3 +58,94 @@ func (r *KMSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 		},
 	}}
 }
+
+// EvaluatePlan reports KMS keys deleted while other resources that are not
+// deleted by the plan still reference them, by ARN or key ID.
+func (r *KMSRule) EvaluatePlan(x *plan.Index) []RuleFinding {
+	var findings []RuleFinding
+	for _, key := range x.ByType("aws_kms_key") {
+		action := key.Change.Actions.ActionType()
+		if key.Mode == "data" || (action != plan.ActionDelete && action != plan.ActionReplace) {
+			continue
+		}
+		before := getBeforeState(*key)
+		var ids [][]byte
+		for _, attr := range []string{"arn", "key_id"} {
+			if v := stringField(before, attr); v != "" {
+				ids = append(ids, []byte(`"`+v+`"`))
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		users := kmsKeyUsers(x, key.Address, ids)
+		if len(users) == 0 {
+			continue
+		}
+		why := []string{fmt.Sprintf("%d resource(s) not deleted by this plan reference the key:", len(users))}
+		for i, u := range users {
+			if i == 10 {
+				why = append(why, fmt.Sprintf("... and %d more", len(users)-10))
+				break
+			}
+			why = append(why, "  "+u)
+		}
+		findings = append(findings, RuleFinding{
+			Severity: SeverityHigh,
+			Tags:     []string{"security", "data"},
+			Title:    fmt.Sprintf("KMS key %s is %sd while still in use", key.Address, action),
+			Address:  key.Address,
+			Why:      why,
+			Recommendations: []string{
+				"Re-encrypt or migrate the listed resources to another key first",
+				"Data encrypted only under this key becomes unreadable once deletion completes",
+			},
+		})
+	}
+	return findings
+}
+
+// kmsKeyUsers returns the addresses of resources whose planned or prior
+// values contain one of ids (quoted JSON strings), excluding the key itself,
+// its aliases and resources the plan deletes.
+func kmsKeyUsers(x *plan.Index, keyAddress string, ids [][]byte) []string {
+	uses := func(values []byte) bool {
+		for _, id := range ids {
+			if bytes.Contains(values, id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	seen := map[string]bool{keyAddress: true}
+	var users []string
+	for _, rc := range x.Plan.ResourceChanges {
+		if seen[rc.Address] || rc.Type == "aws_kms_alias" || rc.Mode == "data" {
+			continue
+		}
+		seen[rc.Address] = true
+		if rc.Change.Actions.ActionType() == plan.ActionDelete {
+			continue
+		}
+		values := rc.Change.After
+		if len(values) == 0 || string(values) == "null" {
+			values = rc.Change.Before
+		}
+		if uses(values) {
+			users = append(users, rc.Address)
+		}
+	}
+	for _, sr := range x.StateResources() {
+		if seen[sr.Address] || sr.Type == "aws_kms_alias" || sr.Mode == "data" {
+			continue
+		}
+		seen[sr.Address] = true
+		if uses(sr.Values) {
+			users = append(users, sr.Address)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
@@ -0,0 +1,45 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+func TestKMSDelete(t *testing.T) {
+	findings := evaluateAll(t, "kms_delete.json")
+	found := false
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && containsTag(f.Tags, "security") && containsTag(f.Tags, "ops") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected HIGH security+ops finding for KMS key delete")
+	}
+}
+
+func TestKMSKeyStillInUse(t *testing.T) {
+	p := loadTestPlan(t, "kms_in_use.json")
+	findings := (&KMSRule{}).EvaluatePlan(plan.NewIndex(p))
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Address != "aws_kms_key.data" || f.Severity != SeverityHigh {
+		t.Errorf("expected HIGH finding on aws_kms_key.data, got %s %s", severityName(f.Severity), f.Address)
+	}
+	want := []string{
+		"  aws_s3_bucket_server_side_encryption_configuration.data",
+		"  aws_sqs_queue.jobs",
+		"  module.db.aws_db_instance.orders",
+	}
+	if len(f.Why) != len(want)+1 {
+		t.Fatalf("expected %d users, got %v", len(want), f.Why)
+	}
+	for i, w := range want {
+		if f.Why[i+1] != w {
+			t.Errorf("user %d: expected %q, got %q", i, w, f.Why[i+1])
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/util"
+)
+
+// DefaultHighRiskManagedPolicies are AWS managed policies equivalent to, or
+// one step away from, full administrator access.
+func DefaultHighRiskManagedPolicies() []string {
+	return []string{
+		"arn:aws:iam::aws:policy/AdministratorAccess",
+		"arn:aws:iam::aws:policy/PowerUserAccess",
+		"arn:aws:iam::aws:policy/IAMFullAccess",
+	}
+}
+
+// attachmentTargets maps policy attachment types to the attributes naming
+// who receives the policy.
+var attachmentTargets = map[string][]string{
+	"aws_iam_role_policy_attachment":  {"role"},
+	"aws_iam_user_policy_attachment":  {"user"},
+	"aws_iam_group_policy_attachment": {"group"},
+	"aws_iam_policy_attachment":       {"roles", "users", "groups"},
+}
+
+// ManagedPolicyRule detects attachments of high-risk managed policies and IAM
+// users gaining long-lived credentials. Attachments carry no policy document
+// in the plan, so IAMPolicyRule cannot see them.
+type ManagedPolicyRule struct {
+	// HighRiskPolicies are policy ARN globs reported when attached.
+	HighRiskPolicies []string
+}
+
+func (r *ManagedPolicyRule) ID() string { return "managed-policy" }
+
+func (r *ManagedPolicyRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
+	action := rc.Change.Actions.ActionType()
+	if action != plan.ActionCreate && action != plan.ActionUpdate && action != plan.ActionReplace {
+		return nil
+	}
+
+	afterData := getAfterState(rc)
+	if afterData == nil {
+		return nil
+	}
+	beforeData := getBeforeState(rc)
+
+	switch rc.Type {
+	case "aws_iam_role":
+		return r.checkManagedPolicyArns(rc, afterData, beforeData)
+	case "aws_iam_user_login_profile":
+		if action != plan.ActionCreate {
+			return nil
+		}
+		return []RuleFinding{userCredentialFinding(rc, afterData,
+			"IAM user %s gains console access",
+			"Console password login is enabled for the user",
+			[]string{
+				"Prefer federated access (IAM Identity Center) over IAM user passwords",
+				"Enforce MFA for the user",
+			})}
+	case "aws_iam_access_key":
+		if action != plan.ActionCreate {
+			return nil
+		}
+		if status, ok := afterData["status"].(string); ok && status == "Inactive" {
+			return nil
+		}
+		return []RuleFinding{userCredentialFinding(rc, afterData,
+			"Long-lived access key created for IAM user %s",
+			"A new access key gives programmatic access that does not expire",
+			[]string{
+				"Prefer IAM roles or OIDC federation for workloads and CI",
+				"If a key is required, rotate it and restrict it with conditions",
+			})}
+	}
+
+	if _, ok := attachmentTargets[rc.Type]; ok {
+		return r.checkAttachment(rc, afterData, beforeData)
+	}
+	return nil
+}
+
+func (r *ManagedPolicyRule) isHighRisk(arn string) bool {
+	for _, p := range r.HighRiskPolicies {
+		if util.MatchGlob(p, arn) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ManagedPolicyRule) checkAttachment(rc plan.ResourceChange, after, before map[string]interface{}) []RuleFinding {
+	arn, _ := after["policy_arn"].(string)
+	if arn == "" || !r.isHighRisk(arn) {
+		return nil
+	}
+	if before != nil {
+		if prev, _ := before["policy_arn"].(string); prev == arn && !targetsGrew(rc.Type, before, after) {
+			return nil
+		}
+	}
+
+	var targets []string
+	for _, attr := range attachmentTargets[rc.Type] {
+		for _, t := range toStringSlice(after[attr]) {
+			targets = append(targets, fmt.Sprintf("%s %s", attr, t))
+		}
+	}
+	why := []string{fmt.Sprintf("Attaches %s", arn)}
+	if len(targets) > 0 {
+		why[0] += " to " + strings.Join(targets, ", ")
+	}
+	why = append(why, "The policy document is not in the plan, so its grants are not otherwise checked")
+
+	return []RuleFinding{highRiskAttachmentFinding(rc.Address, arn, why)}
+}
+
+// targetsGrew reports whether an aws_iam_policy_attachment gained members.
+func targetsGrew(resourceType string, before, after map[string]interface{}) bool {
+	for _, attr := range attachmentTargets[resourceType] {
+		prev := make(map[string]bool)
+		for _, t := range toStringSlice(before[attr]) {
+			prev[t] = true
+		}
+		for _, t := range toStringSlice(after[attr]) {
+			if !prev[t] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *ManagedPolicyRule) checkManagedPolicyArns(rc plan.ResourceChange, after, before map[string]interface{}) []RuleFinding {
+	prev := make(map[string]bool)
+	if before != nil {
+		for _, a := range toStringSlice(before["managed_policy_arns"]) {
+			prev[a] = true
+		}
+	}
+
+	added := toStringSlice(after["managed_policy_arns"])
+	sort.Strings(added)
+
+	var findings []RuleFinding
+	for _, arn := range added {
+		if prev[arn] || !r.isHighRisk(arn) {
+			continue
+		}
+		findings = append(findings, highRiskAttachmentFinding(rc.Address, arn, []string{
+			fmt.Sprintf("managed_policy_arns adds %s", arn),
+			"The policy document is not in the plan, so its grants are not otherwise checked",
+		}))
+	}
+	return findings
+}
+
+func highRiskAttachmentFinding(address, arn string, why []string) RuleFinding {
+	return RuleFinding{
+		Severity: SeverityHigh,
+		Tags:     []string{"security"},
+		Title:    fmt.Sprintf("High-risk managed policy %s attached on %s", policyName(arn), address),
+		Address:  address,
+		Why:      why,
+		Recommendations: []string{
+			"Replace with a customer managed policy scoped to the required actions",
+			"If admin access is intended, confirm it is reviewed and time-bound",
+		},
+	}
+}
+
+func userCredentialFinding(rc plan.ResourceChange, data map[string]interface{}, titleFmt, why string, recs []string) RuleFinding {
+	user, _ := data["user"].(string)
+	if user == "" {
+		user = "(unknown)"
+	}
+	return RuleFinding{
+		Severity:        SeverityMedium,
+		Tags:            []string{"security"},
+		Title:           fmt.Sprintf(titleFmt, user),
+		Address:         rc.Address,
+		Why:             []string{why},
+		Recommendations: recs,
+	}
+}
+
+// policyName returns the last path segment of a policy ARN.
+func policyName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
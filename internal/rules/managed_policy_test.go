@@ -0,0 +1,41 @@
+package rules
+
+import "testing"
+
+func TestManagedPolicyAttachment(t *testing.T) {
+	rule := &ManagedPolicyRule{HighRiskPolicies: DefaultHighRiskManagedPolicies()}
+	p := loadTestPlan(t, "iam_managed_policies.json")
+
+	checkFindings(t, rule.Evaluate(findChange(t, p, "aws_iam_role_policy_attachment.ci_admin")),
+		wantFinding{"High-risk managed policy AdministratorAccess", SeverityHigh, ""})
+	if findings := rule.Evaluate(findChange(t, p, "aws_iam_user_policy_attachment.bob")); len(findings) != 0 {
+		t.Errorf("expected no findings for ReadOnlyAccess, got %v", findings)
+	}
+}
+
+func TestManagedPolicyRoleArns(t *testing.T) {
+	rule := &ManagedPolicyRule{HighRiskPolicies: DefaultHighRiskManagedPolicies()}
+	checkFindings(t, evaluateAddress(t, rule, "iam_managed_policies.json", "aws_iam_role.ops"),
+		wantFinding{"High-risk managed policy IAMFullAccess", SeverityHigh, ""})
+}
+
+func TestManagedPolicyUserCredentials(t *testing.T) {
+	rule := &ManagedPolicyRule{HighRiskPolicies: DefaultHighRiskManagedPolicies()}
+	p := loadTestPlan(t, "iam_managed_policies.json")
+
+	checkFindings(t, rule.Evaluate(findChange(t, p, "aws_iam_user_login_profile.bob")),
+		wantFinding{"IAM user bob gains console access", SeverityMedium, ""})
+	checkFindings(t, rule.Evaluate(findChange(t, p, "aws_iam_access_key.deploy")),
+		wantFinding{"Long-lived access key created for IAM user deploy", SeverityMedium, ""})
+}
+
+func TestManagedPolicyCustomList(t *testing.T) {
+	rule := &ManagedPolicyRule{HighRiskPolicies: []string{"arn:aws:iam::aws:policy/ReadOnly*"}}
+	p := loadTestPlan(t, "iam_managed_policies.json")
+	if findings := rule.Evaluate(findChange(t, p, "aws_iam_user_policy_attachment.bob")); len(findings) != 1 {
+		t.Errorf("expected configured glob to match ReadOnlyAccess, got %v", findings)
+	}
+	if findings := rule.Evaluate(findChange(t, p, "aws_iam_role_policy_attachment.ci_admin")); len(findings) != 0 {
+		t.Errorf("expected AdministratorAccess not reported with a custom list, got %v", findings)
+	}
+}
@@ -0,0 +1,345 @@
+package rules
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+var naclTypes = map[string]bool{
+	"aws_network_acl":         true,
+	"aws_default_network_acl": true,
+	"aws_network_acl_rule":    true,
+}
+
+// NACLRule parses network ACL entries and reports allow-all inbound entries
+// and deny entries made ineffective by a lower-numbered allow. Shadowing is
+// checked across the whole plan, as the entries of one ACL may be separate
+// aws_network_acl_rule resources.
+type NACLRule struct{}
+
+func (r *NACLRule) ID() string { return "nacl" }
+
+// naclEntry is one network ACL entry, normalized across the inline and
+// standalone forms.
+type naclEntry struct {
+	Address  string // resource holding the entry
+	RuleNo   int
+	Egress   bool
+	Allow    bool
+	Protocol string
+	From, To int
+	CIDR     string
+}
+
+func (e naclEntry) direction() string {
+	if e.Egress {
+		return "egress"
+	}
+	return "ingress"
+}
+
+func (e naclEntry) action() string {
+	if e.Allow {
+		return "allow"
+	}
+	return "deny"
+}
+
+// ports returns the port range, treating protocols without ports as the
+// full range.
+func (e naclEntry) ports() (int, int) {
+	switch normProtocol(e.Protocol) {
+	case "tcp", "udp":
+		return e.From, e.To
+	}
+	return 0, 65535
+}
+
+func (e naclEntry) String() string {
+	traffic := "all traffic"
+	if p := normProtocol(e.Protocol); p != "all" {
+		from, to := e.ports()
+		traffic = fmt.Sprintf("%s %d-%d", p, from, to)
+		if from == to {
+			traffic = fmt.Sprintf("%s %d", p, from)
+		}
+	}
+	return fmt.Sprintf("%s %s rule %d (%s, %s)", e.direction(), e.action(), e.RuleNo, traffic, e.CIDR)
+}
+
+// key identifies an entry by what it matches, ignoring its rule number.
+func (e naclEntry) key() string {
+	from, to := e.ports()
+	return fmt.Sprintf("%s|%s|%s|%d-%d|%s", e.direction(), e.action(), normProtocol(e.Protocol), from, to, e.CIDR)
+}
+
+// shadows reports whether e is evaluated before o and matches all of o's
+// traffic, so that o never applies.
+func (e naclEntry) shadows(o naclEntry) bool {
+	if e.Egress != o.Egress || e.RuleNo >= o.RuleNo {
+		return false
+	}
+	ep, op := normProtocol(e.Protocol), normProtocol(o.Protocol)
+	if ep != "all" && ep != op {
+		return false
+	}
+	eFrom, eTo := e.ports()
+	oFrom, oTo := o.ports()
+	if eFrom > oFrom || eTo < oTo {
+		return false
+	}
+	outer, err1 := netip.ParsePrefix(e.CIDR)
+	inner, err2 := netip.ParsePrefix(o.CIDR)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return outer.Bits() <= inner.Bits() && outer.Masked().Contains(inner.Addr())
+}
+
+func (e naclEntry) allowsAllFromInternet() bool {
+	if e.Egress || !e.Allow || (e.CIDR != "0.0.0.0/0" && e.CIDR != "::/0") {
+		return false
+	}
+	from, to := e.ports()
+	p := normProtocol(e.Protocol)
+	return p == "all" || (p == "tcp" && from == 0 && to == 65535)
+}
+
+func (r *NACLRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
+	if !naclTypes[rc.Type] {
+		return nil
+	}
+
+	action := rc.Change.Actions.ActionType()
+	if action == plan.ActionNoop || action == plan.ActionRead || action == plan.ActionDelete {
+		return nil
+	}
+
+	afterData := getAfterState(rc)
+	if afterData == nil {
+		return nil
+	}
+	after := naclEntries(rc.Address, rc.Type, afterData)
+	before := naclEntries(rc.Address, rc.Type, getBeforeState(rc))
+
+	beforeKeys := make(map[string]bool, len(before))
+	for _, e := range before {
+		beforeKeys[e.key()] = true
+	}
+
+	var findings []RuleFinding
+	for _, e := range after {
+		if !e.allowsAllFromInternet() {
+			continue
+		}
+		f := RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"network", "security"},
+			Title:    fmt.Sprintf("Network ACL allows all inbound traffic from the internet on %s (rule %d)", rc.Address, e.RuleNo),
+			Address:  rc.Address,
+			Why:      []string{e.String()},
+			Recommendations: []string{
+				"Allow only the ports the subnet serves, plus ephemeral ports for return traffic",
+			},
+		}
+		if beforeKeys[e.key()] {
+			f.Severity = SeverityLow
+			f.Why = append(f.Why, "Pre-existing: this entry is unchanged by the plan")
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// EvaluatePlan reports deny entries shadowed by a lower-numbered allow of
+// the same ACL, grouping standalone aws_network_acl_rule resources with the
+// ACL they belong to.
+func (r *NACLRule) EvaluatePlan(x *plan.Index) []RuleFinding {
+	var order []string
+	before := make(map[string][]naclEntry)
+	after := make(map[string][]naclEntry)
+	changed := make(map[string]bool)
+	ids := make(map[string]string) // ACL id → address
+
+	var changes []*plan.ResourceChange
+	for _, t := range []string{"aws_network_acl", "aws_default_network_acl", "aws_network_acl_rule"} {
+		for _, rc := range x.ByType(t) {
+			if rc.Mode == "data" {
+				continue
+			}
+			changes = append(changes, rc)
+			for _, data := range []map[string]interface{}{getBeforeState(*rc), getAfterState(*rc)} {
+				if id := stringField(data, "id"); id != "" && t != "aws_network_acl_rule" {
+					ids[id] = rc.Address
+				}
+			}
+		}
+	}
+	for _, rc := range changes {
+		action := rc.Change.Actions.ActionType()
+		changed[rc.Address] = action != plan.ActionNoop && action != plan.ActionRead
+		prev, next := getBeforeState(*rc), getAfterState(*rc)
+		acl := naclOf(x, rc, prev, next, ids)
+		if _, seen := before[acl]; !seen {
+			order = append(order, acl)
+			before[acl] = nil
+		}
+		before[acl] = append(before[acl], naclEntries(rc.Address, rc.Type, prev)...)
+		after[acl] = append(after[acl], naclEntries(rc.Address, rc.Type, next)...)
+	}
+
+	var findings []RuleFinding
+	for _, acl := range order {
+		// Only report shadowing the plan introduces, e.g. by renumbering rules.
+		previouslyShadowed := make(map[string]bool)
+		for _, pair := range shadowedDenies(before[acl]) {
+			previouslyShadowed[pair[1].key()] = true
+		}
+		for _, pair := range shadowedDenies(after[acl]) {
+			allow, deny := pair[0], pair[1]
+			if previouslyShadowed[deny.key()] {
+				continue
+			}
+			address := deny.Address
+			if !changed[address] {
+				address = allow.Address
+			}
+			denyDesc, allowDesc := deny.String(), allow.String()
+			if allow.Address != deny.Address {
+				denyDesc += " in " + deny.Address
+				allowDesc += " in " + allow.Address
+			}
+			findings = append(findings, RuleFinding{
+				Severity: SeverityHigh,
+				Tags:     []string{"network", "security"},
+				Title:    fmt.Sprintf("Network ACL deny rule %d is shadowed on %s", deny.RuleNo, address),
+				Address:  address,
+				Why: []string{
+					fmt.Sprintf("%s never matches", denyDesc),
+					fmt.Sprintf("%s is evaluated first and allows the same traffic", allowDesc),
+				},
+				Recommendations: []string{
+					fmt.Sprintf("Give the deny a rule number lower than %d", allow.RuleNo),
+				},
+			})
+		}
+	}
+	return findings
+}
+
+// naclOf identifies the ACL a resource's entries belong to: the ACL's own
+// address, or for a standalone rule the ACL named by network_acl_id, falling
+// back to the configuration reference while the id is unknown.
+func naclOf(x *plan.Index, rc *plan.ResourceChange, before, after map[string]interface{}, ids map[string]string) string {
+	if rc.Type != "aws_network_acl_rule" {
+		return rc.Address
+	}
+	id := stringField(after, "network_acl_id")
+	if id == "" {
+		id = stringField(before, "network_acl_id")
+	}
+	if id != "" {
+		if address, ok := ids[id]; ok {
+			return address
+		}
+		return id
+	}
+	if ref := referencedResource(x, rc, "network_acl_id"); ref != "" {
+		return ref
+	}
+	return rc.Address
+}
+
+// referencedResource returns the module-qualified address of the resource a
+// configured attribute refers to, e.g. "aws_s3_bucket.logs" for
+// bucket = aws_s3_bucket.logs.id, or "" if it refers to none.
+func referencedResource(x *plan.Index, rc *plan.ResourceChange, attribute string) string {
+	resource := ""
+	for _, ref := range x.References(rc.Address, attribute) {
+		switch strings.SplitN(ref, ".", 2)[0] {
+		case "var", "local", "module", "each", "count", "path", "self", "terraform":
+			continue
+		}
+		// Both "type.name.attr" and "type.name" are listed; keep the latter.
+		if strings.Count(ref, ".") > 0 && (resource == "" || len(ref) < len(resource)) {
+			resource = ref
+		}
+	}
+	if resource != "" && rc.ModuleAddress != "" {
+		resource = rc.ModuleAddress + "." + resource
+	}
+	return resource
+}
+
+// shadowedDenies returns (allow, deny) pairs where the allow makes the deny
+// ineffective, taking the lowest-numbered shadowing allow for each deny.
+func shadowedDenies(entries []naclEntry) [][2]naclEntry {
+	sorted := append([]naclEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].RuleNo < sorted[j].RuleNo })
+
+	var pairs [][2]naclEntry
+	for _, deny := range sorted {
+		if deny.Allow {
+			continue
+		}
+		for _, allow := range sorted {
+			if allow.Allow && allow.shadows(deny) {
+				pairs = append(pairs, [2]naclEntry{allow, deny})
+				break
+			}
+		}
+	}
+	return pairs
+}
+
+func naclEntries(address, resourceType string, data map[string]interface{}) []naclEntry {
+	if data == nil {
+		return nil
+	}
+	if resourceType == "aws_network_acl_rule" {
+		egress, _ := data["egress"].(bool)
+		return []naclEntry{{
+			Address:  address,
+			RuleNo:   intFromJSON(data["rule_number"]),
+			Egress:   egress,
+			Allow:    strings.EqualFold(stringField(data, "rule_action"), "allow"),
+			Protocol: stringField(data, "protocol"),
+			From:     intFromJSON(data["from_port"]),
+			To:       intFromJSON(data["to_port"]),
+			CIDR:     naclCIDR(data),
+		}}
+	}
+
+	var entries []naclEntry
+	for _, dir := range []string{"ingress", "egress"} {
+		list, _ := data[dir].([]interface{})
+		for _, item := range list {
+			block, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			entries = append(entries, naclEntry{
+				Address:  address,
+				RuleNo:   intFromJSON(block["rule_no"]),
+				Egress:   dir == "egress",
+				Allow:    strings.EqualFold(stringField(block, "action"), "allow"),
+				Protocol: stringField(block, "protocol"),
+				From:     intFromJSON(block["from_port"]),
+				To:       intFromJSON(block["to_port"]),
+				CIDR:     naclCIDR(block),
+			})
+		}
+	}
+	return entries
+}
+
+func naclCIDR(data map[string]interface{}) string {
+	if c := stringField(data, "cidr_block"); c != "" {
+		return c
+	}
+	return stringField(data, "ipv6_cidr_block")
+}
@@ -19,6 +19,8 @@ var networkTypes = map[string]bool{
 // NetworkingRule detects risky networking resource changes.
 type NetworkingRule struct{}
 
+func (r *NetworkingRule) ID() string { return "networking" }
+
 func (r *NetworkingRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 	if !networkTypes[rc.Type] {
 		return nil
@@ -0,0 +1,29 @@
+package rules
+
+import "testing"
+
+func TestNetworkingDelete(t *testing.T) {
+	findings := evaluateAll(t, "networking_delete.json")
+	found := false
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && containsTag(f.Tags, "network") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected HIGH network finding for NAT gateway delete")
+	}
+}
+
+func TestNetworkingUpdate(t *testing.T) {
+	findings := evaluateAll(t, "networking_update.json")
+	found := false
+	for _, f := range findings {
+		if f.Severity == SeverityMedium && containsTag(f.Tags, "network") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected MEDIUM network finding for route update")
+	}
+}
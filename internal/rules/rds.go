@@ -3,7 +3,7 @@ package rules
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
+	"reflect"
 
 	"github.com/djeeteg007/tf-why/internal/plan"
 	"github.com/djeeteg007/tf-why/internal/util"
@@ -18,6 +18,8 @@ var rdsTypes = map[string]bool{
 // RDSRule detects risky RDS changes.
 type RDSRule struct{}
 
+func (r *RDSRule) ID() string { return "rds" }
+
 func (r *RDSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 	if !rdsTypes[rc.Type] {
 		return nil
@@ -65,18 +67,191 @@ func (r *RDSRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 		findings = append(findings, r.checkEngineVersion(rc)...)
 	}
 
+	findings = append(findings, checkRDSHardening(rc, action, getBeforeState(rc), getAfterState(rc))...)
 	return findings
 }
 
-func (r *RDSRule) checkEngineVersion(rc plan.ResourceChange) []RuleFinding {
-	var beforeMap, afterMap map[string]interface{}
-	if len(rc.Change.Before) > 0 && string(rc.Change.Before) != "null" {
-		_ = json.Unmarshal(rc.Change.Before, &beforeMap)
+// disruptiveRDSAttributes restart or fail over the database when modified.
+var disruptiveRDSAttributes = []string{
+	"instance_class", "db_cluster_instance_class", "engine_version",
+	"allocated_storage", "storage_type", "iops", "storage_throughput",
+	"multi_az", "port", "ca_cert_identifier", "network_type",
+}
+
+// storageRDSAttributes trigger storage optimization when modified.
+var storageRDSAttributes = []string{"allocated_storage", "storage_type", "iops", "storage_throughput"}
+
+// checkRDSHardening reports changes that weaken the protection, backups,
+// exposure or availability of a database, and modifications that disrupt it.
+func checkRDSHardening(rc plan.ResourceChange, action plan.ActionKind, before, after map[string]interface{}) []RuleFinding {
+	var findings []RuleFinding
+	finding := func(sev int, tags []string, title string, why []string, recs ...string) {
+		findings = append(findings, RuleFinding{
+			Severity:        sev,
+			Tags:            tags,
+			Title:           fmt.Sprintf(title, rc.Address),
+			Address:         rc.Address,
+			Why:             why,
+			Recommendations: recs,
+		})
+	}
+
+	if action == plan.ActionDelete || action == plan.ActionReplace {
+		if skip, _ := before["skip_final_snapshot"].(bool); skip {
+			finding(SeverityHigh, []string{"data"}, "Database %s will be destroyed without a final snapshot",
+				[]string{
+					"skip_final_snapshot = true in the current state",
+					fmt.Sprintf("Applied immediately: the database is %sd when the plan is applied", action),
+					"Automated backups are deleted with the instance unless delete_automated_backups = false",
+				},
+				"Set skip_final_snapshot = false and a final_snapshot_identifier, and apply that first",
+				"Or take a manual snapshot before applying")
+		}
+	}
+	if after == nil {
+		return findings
+	}
+
+	if action == plan.ActionCreate {
+		if enc, ok := after["storage_encrypted"].(bool); ok && !enc {
+			finding(SeverityMedium, []string{"security"}, "Database %s created without storage encryption",
+				[]string{
+					"storage_encrypted = false",
+					"Encryption cannot be enabled later without restoring from an encrypted snapshot copy",
+				},
+				"Set storage_encrypted = true, with a customer managed kms_key_id for sensitive data")
+		}
+		if public, _ := after["publicly_accessible"].(bool); public {
+			finding(SeverityHigh, []string{"security", "network"}, "Database %s is publicly accessible",
+				[]string{"publicly_accessible = true", "Applied at creation"},
+				"Set publicly_accessible = false and reach the database through the VPC")
+		}
+		return findings
 	}
-	if len(rc.Change.After) > 0 && string(rc.Change.After) != "null" {
-		_ = json.Unmarshal(rc.Change.After, &afterMap)
+	if action != plan.ActionUpdate || before == nil {
+		return findings
 	}
 
+	if changedBool(before, after, "deletion_protection", true, false) {
+		finding(SeverityMedium, []string{"data"}, "Deletion protection disabled on %s",
+			[]string{
+				"deletion_protection: true → false",
+				rdsApplyTiming(after, true),
+				"A later plan or console action can delete the database",
+			},
+			"Keep deletion_protection = true on production databases",
+			"If a delete is planned, disable protection in a separate, reviewed change")
+	}
+
+	prevRetention, okBefore := before["backup_retention_period"].(float64)
+	retention, okAfter := after["backup_retention_period"].(float64)
+	if okBefore && okAfter && retention < prevRetention {
+		why := []string{fmt.Sprintf("backup_retention_period: %d → %d days", int(prevRetention), int(retention))}
+		if retention == 0 {
+			finding(SeverityHigh, []string{"data"}, "Automated backups disabled on %s",
+				append(why,
+					"Existing automated backups and point-in-time recovery are removed",
+					rdsApplyTiming(after, false)+"; disabling backups causes a brief outage",
+				),
+				"Keep backup_retention_period at 7 days or more")
+		} else {
+			finding(SeverityMedium, []string{"data"}, "Backup retention reduced on %s",
+				append(why,
+					"Point-in-time recovery no longer reaches as far back; older backups are deleted",
+					rdsApplyTiming(after, true),
+				),
+				"Confirm the shorter retention meets recovery objectives")
+		}
+	}
+
+	if changedBool(before, after, "publicly_accessible", false, true) {
+		finding(SeverityHigh, []string{"security", "network"}, "Database %s becomes publicly accessible",
+			[]string{
+				"publicly_accessible: false → true",
+				rdsApplyTiming(after, true),
+				"The endpoint resolves to a public IP; only security groups restrict access",
+			},
+			"Keep publicly_accessible = false and reach the database through the VPC, a VPN or a bastion")
+	}
+
+	if changedBool(before, after, "multi_az", true, false) {
+		finding(SeverityMedium, []string{"downtime"}, "Multi-AZ disabled on %s",
+			[]string{
+				"multi_az: true → false",
+				"The standby is removed; an AZ failure or maintenance now causes downtime",
+				rdsApplyTiming(after, false),
+			},
+			"Keep multi_az = true on production databases")
+	}
+
+	if changes := rdsAttributeChanges(before, after, storageRDSAttributes); len(changes) > 0 {
+		finding(SeverityMedium, []string{"ops"}, "Storage modification on %s triggers storage optimization",
+			append(changes,
+				"Storage optimization can take several hours and blocks further storage changes for 6 hours",
+				rdsApplyTiming(after, false),
+			),
+			"Plan storage changes ahead of need; performance may degrade while optimizing")
+	}
+
+	if immediate, _ := after["apply_immediately"].(bool); immediate {
+		if changes := rdsAttributeChanges(before, after, disruptiveRDSAttributes); len(changes) > 0 {
+			finding(SeverityMedium, []string{"downtime"}, "Disruptive changes applied immediately on %s",
+				append(changes,
+					"apply_immediately = true: the changes are applied during terraform apply instead of the maintenance window",
+					"Expect a restart or failover when the plan is applied",
+				),
+				"Set apply_immediately = false to defer the changes to the maintenance window",
+				"Or apply during a planned maintenance slot")
+		}
+	}
+	return findings
+}
+
+// changedBool reports whether a boolean attribute moves from one value to
+// the other.
+func changedBool(before, after map[string]interface{}, key string, from, to bool) bool {
+	prev, ok1 := before[key].(bool)
+	next, ok2 := after[key].(bool)
+	return ok1 && ok2 && prev == from && next == to
+}
+
+// rdsAttributeChanges describes the attributes that differ between before
+// and after. Unknown and absent values are ignored.
+func rdsAttributeChanges(before, after map[string]interface{}, keys []string) []string {
+	var changes []string
+	for _, k := range keys {
+		prev, next := before[k], after[k]
+		if prev == nil || next == nil || reflect.DeepEqual(prev, next) {
+			continue
+		}
+		p, _ := json.Marshal(prev)
+		n, _ := json.Marshal(next)
+		changes = append(changes, fmt.Sprintf("%s: %s → %s", k, p, n))
+	}
+	return changes
+}
+
+// rdsApplyTiming explains when a modification takes effect. Some changes are
+// applied as soon as possible whatever apply_immediately says.
+func rdsApplyTiming(after map[string]interface{}, alwaysImmediate bool) string {
+	if alwaysImmediate {
+		return "Applied immediately, regardless of apply_immediately"
+	}
+	if immediate, _ := after["apply_immediately"].(bool); immediate {
+		return "Applied immediately (apply_immediately = true)"
+	}
+	window := stringField(after, "maintenance_window")
+	if window == "" {
+		window = stringField(after, "preferred_maintenance_window")
+	}
+	if window == "" {
+		return "Applied at the next maintenance window (apply_immediately is not set)"
+	}
+	return fmt.Sprintf("Applied at the next maintenance window (%s; apply_immediately is not set)", window)
+}
+
+func (r *RDSRule) checkEngineVersion(rc plan.ResourceChange) []RuleFinding {
+	beforeMap, afterMap := getBeforeState(rc), getAfterState(rc)
 	if beforeMap == nil || afterMap == nil {
 		return nil
 	}
@@ -88,41 +263,53 @@ func (r *RDSRule) checkEngineVersion(rc plan.ResourceChange) []RuleFinding {
 		return nil
 	}
 
+	engine := stringField(afterMap, "engine")
+	if engine == "" {
+		engine = stringField(beforeMap, "engine")
+	}
+	if prev := stringField(beforeMap, "engine"); prev != "" && prev != engine {
+		// An engine change replaces the database; versions are not comparable.
+		return nil
+	}
+
 	severity := SeverityMedium
 	title := fmt.Sprintf("Database engine version change on %s", rc.Address)
+	why := []string{fmt.Sprintf("engine_version: %q → %q", beforeVersion, afterVersion)}
+	recs := []string{
+		"Test the upgrade in a staging environment first",
+		"Review engine changelog for breaking changes",
+		"Schedule during maintenance window",
+	}
 
-	if isMajorVersionBump(beforeVersion, afterVersion) {
+	switch {
+	case compareEngineVersions(afterVersion, beforeVersion) < 0:
+		severity = SeverityHigh
+		title = fmt.Sprintf("Database engine version downgrade on %s", rc.Address)
+		why = append(why, "RDS does not support in-place downgrades: the apply fails, or the database must be restored from a snapshot")
+		recs = []string{
+			"Check whether the version in state was upgraded outside Terraform (e.g. auto minor version upgrade)",
+			"Set engine_version to the running version, or to a major version only (e.g. \"16\") to accept minor upgrades",
+		}
+	case isMajorVersionBump(engine, beforeVersion, afterVersion):
 		severity = SeverityHigh
 		title = fmt.Sprintf("Major database engine version upgrade on %s", rc.Address)
+		why = append(why, fmt.Sprintf("%s major version %s → %s",
+			engineLabel(engine), engineMajorVersion(engine, beforeVersion), engineMajorVersion(engine, afterVersion)))
+		if rc.Type != "aws_rds_cluster_instance" {
+			if allow, _ := afterMap["allow_major_version_upgrade"].(bool); !allow {
+				why = append(why, "allow_major_version_upgrade is not set to true: AWS rejects the upgrade")
+				recs = append(recs, "Set allow_major_version_upgrade = true for this change")
+			}
+		}
 	}
+	why = append(why, rdsApplyTiming(afterMap, false))
 
 	return []RuleFinding{{
-		Severity: severity,
-		Tags:     []string{"downtime"},
-		Title:    title,
-		Address:  rc.Address,
-		Why: []string{
-			fmt.Sprintf("engine_version: %q → %q", beforeVersion, afterVersion),
-		},
-		Recommendations: []string{
-			"Test the upgrade in a staging environment first",
-			"Review engine changelog for breaking changes",
-			"Schedule during maintenance window",
-		},
+		Severity:        severity,
+		Tags:            []string{"downtime"},
+		Title:           title,
+		Address:         rc.Address,
+		Why:             why,
+		Recommendations: recs,
 	}}
 }
-
-// isMajorVersionBump checks if the major version prefix differs.
-func isMajorVersionBump(before, after string) bool {
-	beforeMajor := majorVersion(before)
-	afterMajor := majorVersion(after)
-	return beforeMajor != "" && afterMajor != "" && beforeMajor != afterMajor
-}
-
-func majorVersion(version string) string {
-	parts := strings.SplitN(version, ".", 2)
-	if len(parts) == 0 {
-		return ""
-	}
-	return parts[0]
-}
@@ -0,0 +1,125 @@
+package rules
+
+import "testing"
+
+func TestRDSReplace(t *testing.T) {
+	findings := evaluateAll(t, "rds_replace.json")
+	var hasReplace, hasMajorUpgrade bool
+	for _, f := range findings {
+		if containsTag(f.Tags, "downtime") && containsTag(f.Tags, "data") {
+			hasReplace = true
+		}
+		for _, w := range f.Why {
+			if contains(w, "11.9") && contains(w, "16.1") {
+				hasMajorUpgrade = true
+			}
+		}
+	}
+	if !hasReplace {
+		t.Error("expected HIGH downtime+data finding for RDS replace")
+	}
+	if !hasMajorUpgrade {
+		t.Error("expected engine version diff in findings")
+	}
+}
+
+func TestRDSMinorUpgrade(t *testing.T) {
+	findings := evaluateAll(t, "rds_minor_upgrade.json")
+	found := false
+	for _, f := range findings {
+		if f.Severity == SeverityMedium && containsTag(f.Tags, "downtime") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected MEDIUM downtime finding for minor version upgrade")
+	}
+	// Should NOT be HIGH since major version is the same
+	for _, f := range findings {
+		if contains(f.Title, "Major") {
+			t.Error("minor upgrade should not be flagged as Major")
+		}
+	}
+}
+
+func TestRDSDeleteWithoutSnapshot(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_hardening.json", "aws_db_instance.legacy"),
+		wantFinding{"will be destroyed without a final snapshot", SeverityHigh, "Applied immediately"})
+}
+
+func TestRDSCreateUnencryptedPublic(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_hardening.json", "aws_db_instance.reporting"),
+		wantFinding{"created without storage encryption", SeverityMedium, ""},
+		wantFinding{"is publicly accessible", SeverityHigh, "Applied at creation"})
+}
+
+func TestRDSProtectionRemoved(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_hardening.json", "aws_db_instance.orders"),
+		wantFinding{"Deletion protection disabled", SeverityMedium, "regardless of apply_immediately"},
+		wantFinding{"Automated backups disabled", SeverityHigh, "next maintenance window (sun:03:00-sun:04:00"},
+		wantFinding{"becomes publicly accessible", SeverityHigh, "regardless of apply_immediately"},
+		wantFinding{"Multi-AZ disabled", SeverityMedium, "next maintenance window"})
+}
+
+func TestRDSApplyImmediately(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_hardening.json", "aws_db_instance.billing"),
+		wantFinding{"Backup retention reduced", SeverityMedium, "14 → 7 days"},
+		wantFinding{"triggers storage optimization", SeverityMedium, "Applied immediately (apply_immediately = true)"},
+		wantFinding{"Disruptive changes applied immediately", SeverityMedium, `instance_class: "db.r6g.large" → "db.r6g.xlarge"`})
+}
+
+func TestRDSStorageOptimization(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_hardening.json", "aws_db_instance.events"),
+		wantFinding{"triggers storage optimization", SeverityMedium, "allocated_storage: 100 → 500"})
+}
+
+func TestRDSMajorVersionWithoutAllow(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_versions.json", "aws_db_instance.legacy_pg"),
+		wantFinding{"Major database engine version upgrade", SeverityHigh, "allow_major_version_upgrade is not set"})
+}
+
+func TestRDSAuroraMajorVersion(t *testing.T) {
+	findings := evaluateAddress(t, &RDSRule{}, "rds_versions.json", "aws_rds_cluster.orders")
+	checkFindings(t, findings, wantFinding{"Major database engine version upgrade", SeverityHigh, "aurora-mysql major version 5.7 → 8.0"})
+	if anyContains(findings[0].Why, "allow_major_version_upgrade") {
+		t.Errorf("did not expect allow_major_version_upgrade note when it is set, got %v", findings[0].Why)
+	}
+}
+
+func TestRDSVersionDowngrade(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_versions.json", "aws_db_instance.app_mysql"),
+		wantFinding{"Database engine version downgrade", SeverityHigh, "does not support in-place downgrades"})
+}
+
+func TestRDSVersionChangeTiming(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &RDSRule{}, "rds_versions.json", "aws_db_instance.pg10"),
+		wantFinding{"Database engine version change", SeverityMedium, "next maintenance window (sun:03:00-sun:04:00"})
+}
+
+func TestEngineMajorVersion(t *testing.T) {
+	tests := []struct {
+		engine, version, want string
+	}{
+		{"postgres", "9.6.24", "9.6"},
+		{"postgres", "16.3", "16"},
+		{"aurora-postgresql", "15.4", "15"},
+		{"mysql", "8.0.35", "8.0"},
+		{"mysql", "8.4.3", "8.4"},
+		{"mariadb", "10.11.6", "10.11"},
+		{"aurora-mysql", "5.7.mysql_aurora.2.11.2", "5.7"},
+		{"oracle-ee", "19.0.0.0.ru-2024-01.rur-2024-01.r1", "19"},
+		{"sqlserver-se", "15.00.4345.5.v1", "15"},
+	}
+	for _, tt := range tests {
+		if got := engineMajorVersion(tt.engine, tt.version); got != tt.want {
+			t.Errorf("engineMajorVersion(%q, %q) = %q, want %q", tt.engine, tt.version, got, tt.want)
+		}
+	}
+
+	if compareEngineVersions("8.0.mysql_aurora.3.04.0", "8.0.mysql_aurora.3.10.0") >= 0 {
+		t.Error("expected 3.04.0 to sort before 3.10.0")
+	}
+	if compareEngineVersions("16", "16.3") != 0 {
+		t.Error("expected a partial version to compare equal to its completions")
+	}
+}
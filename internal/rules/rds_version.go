@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"strconv"
+	"strings"
+)
+
+// engineMajorVersion returns the part of an RDS engine version that
+// identifies its major version, following each engine's numbering:
+//
+//	postgres, aurora-postgresql  "9.6.24" → "9.6", "16.3" → "16"
+//	mysql, mariadb               "8.0.35" → "8.0", "10.11.6" → "10.11"
+//	aurora-mysql, aurora         "8.0.mysql_aurora.3.04.0" → "8.0"
+//	oracle-*, sqlserver-*        "19.0.0.0.ru-2024-01.rur-2024-01.r1" → "19"
+//
+// Unknown engines use the first component.
+func engineMajorVersion(engine, version string) string {
+	parts := strings.Split(version, ".")
+	switch {
+	case engine == "postgres" || engine == "aurora-postgresql":
+		// Before PostgreSQL 10 the major version had two components.
+		if n, err := strconv.Atoi(parts[0]); err == nil && n < 10 && len(parts) > 1 {
+			return parts[0] + "." + parts[1]
+		}
+		return parts[0]
+	case engine == "mysql" || engine == "mariadb" || engine == "aurora-mysql" || engine == "aurora":
+		if len(parts) > 1 {
+			return parts[0] + "." + parts[1]
+		}
+		return parts[0]
+	}
+	return parts[0]
+}
+
+// isMajorVersionBump reports whether two versions of an engine differ in
+// major version.
+func isMajorVersionBump(engine, before, after string) bool {
+	return engineMajorVersion(engine, before) != engineMajorVersion(engine, after)
+}
+
+// compareEngineVersions orders two engine versions component by component,
+// numerically where both components are numbers. A version that is a prefix
+// of the other (e.g. "16" and "16.3") compares equal, since Terraform accepts
+// partial versions.
+func compareEngineVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case pa[i] != pb[i]:
+			return strings.Compare(pa[i], pb[i])
+		}
+	}
+	return 0
+}
+
+// engineLabel names an engine for messages.
+func engineLabel(engine string) string {
+	if engine == "" {
+		return "Engine"
+	}
+	return engine
+}
@@ -1,6 +1,8 @@
 package rules
 
 import (
+	"fmt"
+
 	"github.com/djeeteg007/tf-why/internal/plan"
 )
 
@@ -22,19 +24,133 @@ type RuleFinding struct {
 }
 
 // Rule evaluates a single resource change and returns any findings.
+// ID returns a short stable identifier used to reference the rule from
+// configuration (severity overrides, enabled rules).
 type Rule interface {
+	ID() string
 	Evaluate(rc plan.ResourceChange) []RuleFinding
 }
 
-// AllRules returns all registered rules in evaluation order.
+// PlanRule evaluates the whole plan at once, to reason about relationships
+// between resources (e.g. a deleted KMS key and the resources still using
+// it). The index gives access to every resource change, the prior state and
+// the configuration. Findings must be addressed to a resource change in the
+// plan. A rule may implement both Rule and PlanRule under the same ID.
+type PlanRule interface {
+	ID() string
+	EvaluatePlan(x *plan.Index) []RuleFinding
+}
+
+// Config holds user-tunable rule parameters, read from the "rules" section
+// of the configuration file.
+type Config struct {
+	// TrustedAccounts are AWS account IDs that policy principals may
+	// reference without being reported as cross-account access.
+	TrustedAccounts []string `json:"trusted_accounts,omitempty"`
+	// OwnerAccounts are the accounts owning resources in the analyzed plan,
+	// set from PlanAccounts rather than the configuration file.
+	OwnerAccounts []string `json:"-"`
+	// IAMReportPreexisting reports policy risks that an update leaves in
+	// place at LOW severity instead of suppressing them.
+	IAMReportPreexisting bool `json:"iam_report_preexisting,omitempty"`
+	// HighRiskManagedPolicies are policy ARN globs reported when attached
+	// to a role, user or group.
+	HighRiskManagedPolicies []string `json:"high_risk_managed_policies,omitempty"`
+	// SGSensitivePorts maps a port or "from-to" range to a service name.
+	// Entries in the file are merged over DefaultSensitivePorts; an empty
+	// name removes a default.
+	SGSensitivePorts map[string]string `json:"sg_sensitive_ports,omitempty"`
+	// SGWidePortRange is the number of ports from which a publicly open
+	// range is reported on its own. 0 disables the check.
+	SGWidePortRange int `json:"sg_wide_port_range"`
+	// SGBroadPrefixIPv4 and SGBroadPrefixIPv6 are the longest public CIDR
+	// prefixes reported as broad. 0 disables the check.
+	SGBroadPrefixIPv4 int `json:"sg_broad_prefix_ipv4"`
+	SGBroadPrefixIPv6 int `json:"sg_broad_prefix_ipv6"`
+	// SGCheckEgress reports security groups allowing all egress to the
+	// internet.
+	SGCheckEgress bool `json:"sg_check_egress,omitempty"`
+	// SGSensitiveTierNames are security group name globs (matched against
+	// the lowercased name) marking sensitive tiers, whose egress to the
+	// internet is reported at MEDIUM.
+	SGSensitiveTierNames []string `json:"sg_sensitive_tier_names,omitempty"`
+	// SGSensitiveTierTags maps a tag key to a value glob marking a security
+	// group as a sensitive tier. Entries in the file are merged over the
+	// defaults; an empty glob removes a default.
+	SGSensitiveTierTags map[string]string `json:"sg_sensitive_tier_tags,omitempty"`
+	// StatefulResources is the catalog of resource types whose delete or
+	// replace loses data. Entries in the file are merged over
+	// DefaultStatefulResources; null removes a default.
+	StatefulResources map[string]*StatefulResource `json:"stateful_resources,omitempty"`
+}
+
+// Validate checks values that cannot be expressed in the JSON types.
+func (c Config) Validate() error {
+	for ports := range c.SGSensitivePorts {
+		if _, _, err := ParsePortRange(ports); err != nil {
+			return fmt.Errorf("sg_sensitive_ports: %w", err)
+		}
+	}
+	if c.SGWidePortRange < 0 || c.SGBroadPrefixIPv4 < 0 || c.SGBroadPrefixIPv6 < 0 {
+		return fmt.Errorf("sg_wide_port_range and sg_broad_prefix_* must not be negative")
+	}
+	return nil
+}
+
+// DefaultConfig returns the built-in rule parameters.
+func DefaultConfig() Config {
+	return Config{
+		HighRiskManagedPolicies: DefaultHighRiskManagedPolicies(),
+		SGSensitivePorts:        DefaultSensitivePorts(),
+		SGWidePortRange:         1000,
+		SGBroadPrefixIPv4:       8,
+		SGBroadPrefixIPv6:       32,
+		SGSensitiveTierNames:    DefaultSensitiveTierNames(),
+		SGSensitiveTierTags:     map[string]string{"Tier": "data"},
+		StatefulResources:       DefaultStatefulResources(),
+	}
+}
+
+// PlanRules returns all registered plan-wide rules, configured by cfg.
+func PlanRules(cfg Config) []PlanRule {
+	return []PlanRule{
+		&AuroraTopologyRule{},
+		&EKSRule{},
+		&KMSRule{},
+		&NACLRule{},
+		&S3Rule{},
+	}
+}
+
+// AllRules returns all registered rules with the default configuration.
 func AllRules() []Rule {
+	return Rules(DefaultConfig())
+}
+
+// Rules returns all registered rules in evaluation order, configured by cfg.
+func Rules(cfg Config) []Rule {
 	return []Rule{
-		&IAMPolicyRule{},
-		&SecurityGroupRule{},
+		&IAMPolicyRule{TrustedAccounts: cfg.TrustedAccounts, OwnerAccounts: cfg.OwnerAccounts, ReportPreexisting: cfg.IAMReportPreexisting},
+		&TrustPolicyRule{TrustedAccounts: cfg.TrustedAccounts, OwnerAccounts: cfg.OwnerAccounts, ReportPreexisting: cfg.IAMReportPreexisting},
+		&ManagedPolicyRule{HighRiskPolicies: cfg.HighRiskManagedPolicies},
+		&SecurityGroupRule{
+			SensitivePorts:     cfg.SGSensitivePorts,
+			WidePortRange:      cfg.SGWidePortRange,
+			BroadPrefixIPv4:    cfg.SGBroadPrefixIPv4,
+			BroadPrefixIPv6:    cfg.SGBroadPrefixIPv6,
+			CheckEgress:        cfg.SGCheckEgress,
+			SensitiveTierNames: cfg.SGSensitiveTierNames,
+			SensitiveTierTags:  cfg.SGSensitiveTierTags,
+		},
 		&RDSRule{},
 		&ECSRule{},
+		&EKSRule{},
+		&ComputeRule{},
 		&NetworkingRule{},
+		&NACLRule{},
 		&KMSRule{},
-		&GenericRule{}, // generic rules run last as catch-all
+		&S3Rule{},
+		&DynamoDBRule{},
+		&GenericRule{Stateful: cfg.StatefulResources}, // generic rules run last as catch-all
 	}
 }
@@ -38,269 +38,61 @@ func evaluateAll(t *testing.T, fixture string) []RuleFinding {
 	return findings
 }
 
-// --- Generic Rule Tests ---
-
-func TestGenericReplace(t *testing.T) {
-	findings := evaluateAll(t, "generic_replace.json")
-	if len(findings) == 0 {
-		t.Fatal("expected findings for replace")
-	}
-	found := false
-	for _, f := range findings {
-		if f.Severity == SeverityHigh && containsTag(f.Tags, "downtime") {
-			found = true
-			break
-		}
-	}
-	if !found {
-		t.Error("expected HIGH severity with downtime tag for replace")
-	}
-}
-
-func TestGenericDelete(t *testing.T) {
-	findings := evaluateAll(t, "generic_delete.json")
-	if len(findings) == 0 {
-		t.Fatal("expected findings for delete")
-	}
-	found := false
-	for _, f := range findings {
-		if f.Severity == SeverityHigh && containsTag(f.Tags, "ops") {
-			found = true
-			break
-		}
-	}
-	if !found {
-		t.Error("expected HIGH severity with ops tag for delete")
-	}
-}
-
-// --- IAM Rule Tests ---
-
-func TestIAMWildcardAction(t *testing.T) {
-	findings := evaluateAll(t, "iam_wildcard.json")
-	if len(findings) == 0 {
-		t.Fatal("expected findings for wildcard IAM policy")
-	}
-	var hasWildcardAction, hasWildcardResource bool
-	for _, f := range findings {
-		if f.Severity == SeverityHigh && containsTag(f.Tags, "security") {
-			for _, w := range f.Why {
-				if contains(w, "Action") && contains(w, "\"*\"") {
-					hasWildcardAction = true
-				}
-				if contains(w, "Resource") && contains(w, "\"*\"") {
-					hasWildcardResource = true
-				}
-			}
-		}
-	}
-	if !hasWildcardAction {
-		t.Error("expected finding for wildcard Action")
-	}
-	if !hasWildcardResource {
-		t.Error("expected finding for wildcard Resource")
-	}
-}
-
-func TestIAMServiceWildcard(t *testing.T) {
-	findings := evaluateAll(t, "iam_service_wildcard.json")
-	found := false
-	for _, f := range findings {
-		for _, w := range f.Why {
-			if contains(w, "s3:*") {
-				found = true
-			}
-		}
-	}
-	if !found {
-		t.Error("expected finding for s3:* wildcard action")
-	}
-}
-
-func TestIAMPassRole(t *testing.T) {
-	findings := evaluateAll(t, "iam_passrole.json")
-	var hasPassRole, hasAssumeRole bool
-	for _, f := range findings {
-		for _, w := range f.Why {
-			if contains(w, "iam:PassRole") {
-				hasPassRole = true
-			}
-			if contains(w, "sts:AssumeRole") {
-				hasAssumeRole = true
-			}
-		}
-	}
-	if !hasPassRole {
-		t.Error("expected finding for iam:PassRole")
-	}
-	if !hasAssumeRole {
-		t.Error("expected finding for sts:AssumeRole")
-	}
-}
-
-func TestS3PublicAccess(t *testing.T) {
-	findings := evaluateAll(t, "s3_public_access.json")
-	found := false
-	for _, f := range findings {
-		if containsTag(f.Tags, "security") {
-			for _, w := range f.Why {
-				if contains(w, "block_public_acls") || contains(w, "block_public_policy") {
-					found = true
-				}
-			}
-		}
-	}
-	if !found {
-		t.Error("expected finding for weakened S3 public access block")
-	}
-}
-
-// --- Security Group Rule Tests ---
-
-func TestSGOpenSSH(t *testing.T) {
-	findings := evaluateAll(t, "sg_open_ssh.json")
-	found := false
-	for _, f := range findings {
-		if f.Severity == SeverityHigh && containsTag(f.Tags, "security") {
-			for _, w := range f.Why {
-				if contains(w, "0.0.0.0/0") && contains(w, "22") {
-					found = true
-				}
-			}
-		}
-	}
-	if !found {
-		t.Error("expected HIGH security finding for SSH open to internet")
-	}
-}
-
-func TestSGInlineAllPorts(t *testing.T) {
-	findings := evaluateAll(t, "sg_inline_multi.json")
-	if len(findings) == 0 {
-		t.Fatal("expected findings for wide-open security group")
-	}
-	// Should find all dangerous ports
-	ports := map[string]bool{}
-	for _, f := range findings {
-		for _, w := range f.Why {
-			for _, svc := range []string{"SSH", "RDP", "PostgreSQL", "MySQL", "Elasticsearch", "Redis"} {
-				if contains(w, svc) {
-					ports[svc] = true
-				}
-			}
+// findChange returns the resource change at address in a test fixture.
+func findChange(t *testing.T, p *plan.Plan, address string) plan.ResourceChange {
+	t.Helper()
+	for _, rc := range p.ResourceChanges {
+		if rc.Address == address {
+			return rc
 		}
 	}
-	if len(ports) < 6 {
-		t.Errorf("expected all 6 dangerous ports flagged, got %d: %v", len(ports), ports)
-	}
+	t.Fatalf("no resource change %s in test fixture", address)
+	return plan.ResourceChange{}
 }
 
-// --- RDS Rule Tests ---
-
-func TestRDSReplace(t *testing.T) {
-	findings := evaluateAll(t, "rds_replace.json")
-	var hasReplace, hasMajorUpgrade bool
-	for _, f := range findings {
-		if containsTag(f.Tags, "downtime") && containsTag(f.Tags, "data") {
-			hasReplace = true
-		}
-		for _, w := range f.Why {
-			if contains(w, "11.9") && contains(w, "16.1") {
-				hasMajorUpgrade = true
-			}
-		}
-	}
-	if !hasReplace {
-		t.Error("expected HIGH downtime+data finding for RDS replace")
-	}
-	if !hasMajorUpgrade {
-		t.Error("expected engine version diff in findings")
-	}
+// evaluateAddress evaluates rule on the resource change at address in a
+// test fixture.
+func evaluateAddress(t *testing.T, rule Rule, fixture, address string) []RuleFinding {
+	t.Helper()
+	return rule.Evaluate(findChange(t, loadTestPlan(t, fixture), address))
 }
 
-func TestRDSMinorUpgrade(t *testing.T) {
-	findings := evaluateAll(t, "rds_minor_upgrade.json")
-	found := false
-	for _, f := range findings {
-		if f.Severity == SeverityMedium && containsTag(f.Tags, "downtime") {
-			found = true
-		}
-	}
-	if !found {
-		t.Error("expected MEDIUM downtime finding for minor version upgrade")
-	}
-	// Should NOT be HIGH since major version is the same
-	for _, f := range findings {
-		if contains(f.Title, "Major") {
-			t.Error("minor upgrade should not be flagged as Major")
+// configuredRule returns the rule with the given ID built from the default
+// configuration.
+func configuredRule(t *testing.T, id string) Rule {
+	t.Helper()
+	for _, r := range Rules(DefaultConfig()) {
+		if r.ID() == id {
+			return r
 		}
 	}
+	t.Fatalf("no rule %s", id)
+	return nil
 }
 
-// --- ECS Rule Tests ---
-
-func TestECSScaleDown(t *testing.T) {
-	findings := evaluateAll(t, "ecs_scale_down.json")
-	var hasCount, hasHealthy bool
-	for _, f := range findings {
-		for _, w := range f.Why {
-			if contains(w, "desired_count") && contains(w, "4") && contains(w, "1") {
-				hasCount = true
-			}
-			if contains(w, "deployment_minimum_healthy_percent") {
-				hasHealthy = true
-			}
-		}
-	}
-	if !hasCount {
-		t.Error("expected finding for desired_count decrease")
-	}
-	if !hasHealthy {
-		t.Error("expected finding for deployment_minimum_healthy_percent decrease")
-	}
+// wantFinding is an expected finding: a substring of its title, its
+// severity and, unless empty, a substring of one of its Why lines.
+type wantFinding struct {
+	title string
+	sev   int
+	why   string
 }
 
-// --- Networking Rule Tests ---
-
-func TestNetworkingDelete(t *testing.T) {
-	findings := evaluateAll(t, "networking_delete.json")
-	found := false
-	for _, f := range findings {
-		if f.Severity == SeverityHigh && containsTag(f.Tags, "network") {
-			found = true
-		}
-	}
-	if !found {
-		t.Error("expected HIGH network finding for NAT gateway delete")
+// checkFindings fails the test unless findings match want, in order.
+func checkFindings(t *testing.T, findings []RuleFinding, want ...wantFinding) {
+	t.Helper()
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %v", len(want), len(findings), findings)
 	}
-}
-
-func TestNetworkingUpdate(t *testing.T) {
-	findings := evaluateAll(t, "networking_update.json")
-	found := false
-	for _, f := range findings {
-		if f.Severity == SeverityMedium && containsTag(f.Tags, "network") {
-			found = true
+	for i, w := range want {
+		f := findings[i]
+		if !contains(f.Title, w.title) || f.Severity != w.sev {
+			t.Errorf("finding %d: expected %s %q, got %s %q", i, severityName(w.sev), w.title, severityName(f.Severity), f.Title)
 		}
-	}
-	if !found {
-		t.Error("expected MEDIUM network finding for route update")
-	}
-}
-
-// --- KMS Rule Tests ---
-
-func TestKMSDelete(t *testing.T) {
-	findings := evaluateAll(t, "kms_delete.json")
-	found := false
-	for _, f := range findings {
-		if f.Severity == SeverityHigh && containsTag(f.Tags, "security") && containsTag(f.Tags, "ops") {
-			found = true
+		if w.why != "" && !anyContains(f.Why, w.why) {
+			t.Errorf("finding %d: expected why containing %q, got %v", i, w.why, f.Why)
 		}
 	}
-	if !found {
-		t.Error("expected HIGH security+ops finding for KMS key delete")
-	}
 }
 
 // --- No Change Test ---
@@ -334,6 +126,18 @@ func TestSensitiveUnknownNoCrash(t *testing.T) {
 
 // --- Helpers ---
 
+func severityName(sev int) string {
+	switch sev {
+	case SeverityLow:
+		return "LOW"
+	case SeverityMedium:
+		return "MEDIUM"
+	case SeverityHigh:
+		return "HIGH"
+	}
+	return "UNKNOWN"
+}
+
 func containsTag(tags []string, tag string) bool {
 	for _, t := range tags {
 		if t == tag {
@@ -355,3 +159,13 @@ func containsSubstr(s, sub string) bool {
 	}
 	return false
 }
+
+// anyContains reports whether any line contains sub.
+func anyContains(lines []string, sub string) bool {
+	for _, l := range lines {
+		if contains(l, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,440 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+// S3Rule detects changes that weaken data protection on S3 buckets:
+// destructive deletes, versioning, encryption, ACLs, lifecycle expiration
+// and object lock retention. Lifecycle expiration is checked across the plan
+// to take the bucket's versioning into account.
+type S3Rule struct{}
+
+func (r *S3Rule) ID() string { return "s3" }
+
+func (r *S3Rule) Evaluate(rc plan.ResourceChange) []RuleFinding {
+	action := rc.Change.Actions.ActionType()
+	if action == plan.ActionNoop || action == plan.ActionRead {
+		return nil
+	}
+
+	after := getAfterState(rc)
+	before := getBeforeState(rc)
+
+	switch rc.Type {
+	case "aws_s3_bucket":
+		return checkForceDestroy(rc, action, before)
+	case "aws_s3_bucket_versioning":
+		return checkVersioning(rc, action, before, after)
+	case "aws_s3_bucket_server_side_encryption_configuration":
+		return checkBucketEncryption(rc, action, before, after)
+	case "aws_s3_bucket_acl":
+		return checkBucketACL(rc, before, after)
+	case "aws_s3_bucket_object_lock_configuration":
+		return checkObjectLock(rc, action, before, after)
+	case "aws_s3_bucket_ownership_controls":
+		return checkOwnershipControls(rc, action, before, after)
+	}
+	return nil
+}
+
+// checkForceDestroy reports buckets destroyed with force_destroy, which
+// deletes every object instead of failing on a non-empty bucket. Terraform
+// honours the value from the prior state.
+func checkForceDestroy(rc plan.ResourceChange, action plan.ActionKind, before map[string]interface{}) []RuleFinding {
+	if action != plan.ActionDelete && action != plan.ActionReplace {
+		return nil
+	}
+	if force, _ := before["force_destroy"].(bool); !force {
+		return nil
+	}
+	return []RuleFinding{{
+		Severity: SeverityHigh,
+		Tags:     []string{"data"},
+		Title:    fmt.Sprintf("Bucket %s will be %sd with force_destroy — all objects are deleted", rc.Address, action),
+		Address:  rc.Address,
+		Why: []string{
+			fmt.Sprintf("force_destroy = true on bucket %s", bucketName(rc, before)),
+			"Terraform empties the bucket, including all object versions, instead of failing",
+		},
+		Recommendations: []string{
+			"Back up or replicate the bucket contents before applying",
+			"Set force_destroy = false on buckets holding data that must survive",
+		},
+	}}
+}
+
+// checkVersioning reports versioning turned off on a bucket where it was
+// enabled. A new bucket that starts unversioned is not a regression.
+func checkVersioning(rc plan.ResourceChange, action plan.ActionKind, before, after map[string]interface{}) []RuleFinding {
+	prev := stringField(firstBlock(before, "versioning_configuration"), "status")
+	next := stringField(firstBlock(after, "versioning_configuration"), "status")
+	if action == plan.ActionDelete {
+		// Deleting the resource suspends versioning on the bucket.
+		next = "Suspended"
+	}
+	if prev != "Enabled" || (next != "Suspended" && next != "Disabled") {
+		return nil
+	}
+
+	why := fmt.Sprintf("versioning_configuration.status: %q → %q", prev, next)
+	if action == plan.ActionDelete {
+		why = "Removing aws_s3_bucket_versioning suspends versioning on the bucket"
+	}
+	return []RuleFinding{{
+		Severity: SeverityMedium,
+		Tags:     []string{"data"},
+		Title:    fmt.Sprintf("S3 versioning suspended on %s", rc.Address),
+		Address:  rc.Address,
+		Why: []string{
+			why,
+			"Overwritten and deleted objects can no longer be recovered from previous versions",
+		},
+		Recommendations: []string{
+			"Keep versioning enabled on buckets holding data",
+			"Use noncurrent version expiration to control the cost of old versions instead",
+		},
+	}}
+}
+
+func checkBucketEncryption(rc plan.ResourceChange, action plan.ActionKind, before, after map[string]interface{}) []RuleFinding {
+	prev := sseAlgorithm(before)
+	next := sseAlgorithm(after)
+
+	var why string
+	switch {
+	case !strings.HasPrefix(prev, "aws:kms"):
+		// Removing an SSE-S3 configuration keeps the default SSE-S3.
+		return nil
+	case action == plan.ActionDelete:
+		why = fmt.Sprintf("Removing the %s encryption configuration reverts the bucket to the default SSE-S3 (AES256)", prev)
+	case next == "AES256":
+		why = fmt.Sprintf("sse_algorithm: %q → %q", prev, next)
+	default:
+		return nil
+	}
+
+	return []RuleFinding{{
+		Severity: SeverityMedium,
+		Tags:     []string{"security", "data"},
+		Title:    fmt.Sprintf("S3 default encryption downgraded on %s", rc.Address),
+		Address:  rc.Address,
+		Why: []string{
+			why,
+			"New objects are no longer encrypted with a KMS key, so key policies and CloudTrail key usage no longer control access to them",
+		},
+		Recommendations: []string{
+			"Keep aws:kms encryption with a customer managed key for sensitive data",
+			"If SSE-S3 is intended, confirm no compliance requirement mandates KMS",
+		},
+	}}
+}
+
+// publicGrantees are ACL grantee groups that make a bucket public.
+var publicGrantees = map[string]string{
+	"http://acs.amazonaws.com/groups/global/AllUsers":           "everyone",
+	"http://acs.amazonaws.com/groups/global/AuthenticatedUsers": "any AWS account",
+}
+
+func checkBucketACL(rc plan.ResourceChange, before, after map[string]interface{}) []RuleFinding {
+	if after == nil {
+		return nil
+	}
+	grants := publicACLGrants(after)
+	prevGrants := make(map[string]bool)
+	for _, g := range publicACLGrants(before) {
+		prevGrants[g] = true
+	}
+
+	var why []string
+	for _, g := range grants {
+		if !prevGrants[g] {
+			why = append(why, g)
+		}
+	}
+	if len(why) == 0 {
+		return nil
+	}
+	return []RuleFinding{{
+		Severity: SeverityHigh,
+		Tags:     []string{"security", "data"},
+		Title:    fmt.Sprintf("S3 bucket ACL grants public access on %s", rc.Address),
+		Address:  rc.Address,
+		Why:      why,
+		Recommendations: []string{
+			"Use the private ACL and grant access through bucket policies or CloudFront",
+			"Disable ACLs with aws_s3_bucket_ownership_controls (BucketOwnerEnforced)",
+		},
+	}}
+}
+
+// publicACLGrants describes the public grants of a canned or explicit ACL.
+func publicACLGrants(data map[string]interface{}) []string {
+	if data == nil {
+		return nil
+	}
+	var grants []string
+	switch acl := stringField(data, "acl"); acl {
+	case "public-read":
+		grants = append(grants, `acl = "public-read": anyone can list and read objects`)
+	case "public-read-write":
+		grants = append(grants, `acl = "public-read-write": anyone can list, read, write and delete objects`)
+	case "authenticated-read":
+		grants = append(grants, `acl = "authenticated-read": any AWS account can list and read objects`)
+	}
+
+	policy := firstBlock(data, "access_control_policy")
+	list, _ := policy["grant"].([]interface{})
+	for _, item := range list {
+		g, _ := item.(map[string]interface{})
+		uri := stringField(firstBlock(g, "grantee"), "uri")
+		if who, ok := publicGrantees[uri]; ok {
+			grants = append(grants, fmt.Sprintf("access_control_policy grants %s to %s", stringField(g, "permission"), who))
+		}
+	}
+	return grants
+}
+
+// EvaluatePlan reports lifecycle rules that start expiring current objects,
+// explaining what expiration does given the bucket's versioning.
+func (r *S3Rule) EvaluatePlan(x *plan.Index) []RuleFinding {
+	var findings []RuleFinding
+	for _, rc := range x.ByType("aws_s3_bucket_lifecycle_configuration") {
+		action := rc.Change.Actions.ActionType()
+		if rc.Mode == "data" || action == plan.ActionNoop || action == plan.ActionRead || action == plan.ActionDelete {
+			continue
+		}
+		after := getAfterState(*rc)
+		findings = append(findings, checkLifecycleExpiration(*rc, getBeforeState(*rc), after, s3Versioning(x, rc, after))...)
+	}
+	return findings
+}
+
+// s3Versioning returns the planned versioning status of the bucket a
+// resource configures, matched by bucket name or configuration reference,
+// or "" if it is unknown.
+func s3Versioning(x *plan.Index, rc *plan.ResourceChange, data map[string]interface{}) string {
+	name := stringField(data, "bucket")
+	ref := referencedResource(x, rc, "bucket")
+	sameBucket := func(other *plan.ResourceChange, otherData map[string]interface{}) bool {
+		if name != "" && stringField(otherData, "bucket") == name {
+			return true
+		}
+		return ref != "" && (other.Address == ref || referencedResource(x, other, "bucket") == ref)
+	}
+
+	for _, v := range x.ByType("aws_s3_bucket_versioning") {
+		if v.Change.Actions.ActionType() == plan.ActionDelete {
+			if sameBucket(v, getBeforeState(*v)) {
+				return "Suspended"
+			}
+			continue
+		}
+		if after := getAfterState(*v); sameBucket(v, after) {
+			return stringField(firstBlock(after, "versioning_configuration"), "status")
+		}
+	}
+	// Buckets managed with AWS provider v3 configure versioning inline.
+	for _, b := range x.ByType("aws_s3_bucket") {
+		after := getAfterState(*b)
+		if b.Mode == "data" || !sameBucket(b, after) {
+			continue
+		}
+		if enabled, ok := firstBlock(after, "versioning")["enabled"].(bool); ok && enabled {
+			return "Enabled"
+		}
+	}
+	return ""
+}
+
+func checkLifecycleExpiration(rc plan.ResourceChange, before, after map[string]interface{}, versioning string) []RuleFinding {
+	prev := lifecycleRules(before)
+
+	effect := "Without versioning, expired objects are permanently deleted"
+	switch versioning {
+	case "Enabled":
+		effect = "Versioning is enabled on the bucket: expired objects become noncurrent versions and stay recoverable until a noncurrent_version_expiration rule deletes them"
+	case "Suspended", "Disabled":
+		effect = fmt.Sprintf("Versioning is %s on the bucket: expired objects are permanently deleted", strings.ToLower(versioning))
+	}
+
+	var findings []RuleFinding
+	list, _ := after["rule"].([]interface{})
+	for i, item := range list {
+		rule, _ := item.(map[string]interface{})
+		id := lifecycleRuleID(rule, i)
+		exp := lifecycleExpiration(rule)
+		if exp == "" {
+			continue
+		}
+		why := []string{fmt.Sprintf("Rule %q expires current object versions %s", id, exp)}
+		if old, ok := prev[id]; ok {
+			prevExp := lifecycleExpiration(old)
+			if prevExp == exp {
+				continue
+			}
+			// A longer expiration keeps objects longer.
+			prevDays := intFromJSON(firstBlock(old, "expiration")["days"])
+			if prevDays > 0 && intFromJSON(firstBlock(rule, "expiration")["days"]) > prevDays {
+				continue
+			}
+			if prevExp != "" {
+				why = append(why, fmt.Sprintf("Previously expired %s", prevExp))
+			}
+		}
+		findings = append(findings, RuleFinding{
+			Severity: SeverityMedium,
+			Tags:     []string{"data"},
+			Title:    fmt.Sprintf("S3 lifecycle rule %q expires current objects on %s", id, rc.Address),
+			Address:  rc.Address,
+			Why: append(why,
+				effect,
+				"Check the rule filter: an empty filter applies to every object in the bucket",
+			),
+			Recommendations: []string{
+				"Confirm the retention period matches the data's requirements",
+				"Prefer noncurrent_version_expiration on versioned buckets",
+			},
+		})
+	}
+	return findings
+}
+
+// lifecycleRules maps lifecycle rule IDs to their blocks.
+func lifecycleRules(data map[string]interface{}) map[string]map[string]interface{} {
+	rules := make(map[string]map[string]interface{})
+	list, _ := data["rule"].([]interface{})
+	for i, item := range list {
+		rule, _ := item.(map[string]interface{})
+		rules[lifecycleRuleID(rule, i)] = rule
+	}
+	return rules
+}
+
+func lifecycleRuleID(rule map[string]interface{}, index int) string {
+	if id := stringField(rule, "id"); id != "" {
+		return id
+	}
+	return fmt.Sprintf("rule[%d]", index)
+}
+
+// lifecycleExpiration describes when an enabled rule expires current
+// versions, or returns "" if it does not.
+func lifecycleExpiration(rule map[string]interface{}) string {
+	if stringField(rule, "status") != "Enabled" {
+		return ""
+	}
+	exp := firstBlock(rule, "expiration")
+	if days := intFromJSON(exp["days"]); days > 0 {
+		return fmt.Sprintf("after %d days", days)
+	}
+	if date := stringField(exp, "date"); date != "" {
+		return "on " + date
+	}
+	return ""
+}
+
+func checkObjectLock(rc plan.ResourceChange, action plan.ActionKind, before, after map[string]interface{}) []RuleFinding {
+	prevMode, prevDays := objectLockRetention(before)
+	if prevMode == "" {
+		return nil
+	}
+	nextMode, nextDays := objectLockRetention(after)
+
+	var why []string
+	switch {
+	case action == plan.ActionDelete || nextMode == "":
+		why = append(why, fmt.Sprintf("Default %s retention of %d days is removed", prevMode, prevDays))
+	default:
+		if prevMode == "COMPLIANCE" && nextMode == "GOVERNANCE" {
+			why = append(why, "Retention mode: COMPLIANCE → GOVERNANCE (users with s3:BypassGovernanceRetention can delete locked objects)")
+		}
+		if nextDays < prevDays {
+			why = append(why, fmt.Sprintf("Default retention: %d → %d days", prevDays, nextDays))
+		}
+	}
+	if len(why) == 0 {
+		return nil
+	}
+	return []RuleFinding{{
+		Severity: SeverityMedium,
+		Tags:     []string{"data", "security"},
+		Title:    fmt.Sprintf("S3 object lock retention reduced on %s", rc.Address),
+		Address:  rc.Address,
+		Why: append(why,
+			"New objects can be deleted or overwritten sooner; existing objects keep their retention",
+		),
+		Recommendations: []string{
+			"Confirm the reduced retention still meets legal and compliance requirements",
+		},
+	}}
+}
+
+// objectLockRetention returns the default retention mode and period in days.
+func objectLockRetention(data map[string]interface{}) (string, int) {
+	ret := firstBlock(firstBlock(data, "rule"), "default_retention")
+	mode := stringField(ret, "mode")
+	if mode == "" {
+		return "", 0
+	}
+	return mode, intFromJSON(ret["days"]) + 365*intFromJSON(ret["years"])
+}
+
+func checkOwnershipControls(rc plan.ResourceChange, action plan.ActionKind, before, after map[string]interface{}) []RuleFinding {
+	prev := stringField(firstBlock(before, "rule"), "object_ownership")
+	next := stringField(firstBlock(after, "rule"), "object_ownership")
+
+	var why string
+	switch {
+	case action == plan.ActionDelete:
+		if prev != "BucketOwnerEnforced" {
+			return nil
+		}
+		why = "Removing the ownership controls re-enables ACLs (ObjectWriter)"
+	case next == "" || next == "BucketOwnerEnforced" || next == prev:
+		return nil
+	case prev == "":
+		why = fmt.Sprintf("object_ownership is %q", next)
+	default:
+		why = fmt.Sprintf("object_ownership: %q → %q", prev, next)
+	}
+
+	return []RuleFinding{{
+		Severity: SeverityMedium,
+		Tags:     []string{"security"},
+		Title:    fmt.Sprintf("S3 ACLs enabled by ownership controls on %s", rc.Address),
+		Address:  rc.Address,
+		Why: []string{
+			why,
+			"Object and bucket ACLs take effect again and can grant access outside bucket policies",
+		},
+		Recommendations: []string{
+			"Keep object_ownership = \"BucketOwnerEnforced\" unless a consumer requires ACLs",
+		},
+	}}
+}
+
+func sseAlgorithm(data map[string]interface{}) string {
+	rule := firstBlock(data, "rule")
+	return stringField(firstBlock(rule, "apply_server_side_encryption_by_default"), "sse_algorithm")
+}
+
+func bucketName(rc plan.ResourceChange, data map[string]interface{}) string {
+	if name := stringField(data, "bucket"); name != "" {
+		return name
+	}
+	return rc.Address
+}
+
+// firstBlock returns the first element of a nested block list, or nil.
+func firstBlock(data map[string]interface{}, key string) map[string]interface{} {
+	list, _ := data[key].([]interface{})
+	if len(list) == 0 {
+		return nil
+	}
+	block, _ := list[0].(map[string]interface{})
+	return block
+}
@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+func TestS3PublicAccess(t *testing.T) {
+	findings := evaluateAll(t, "s3_public_access.json")
+	found := false
+	for _, f := range findings {
+		if containsTag(f.Tags, "security") {
+			for _, w := range f.Why {
+				if contains(w, "block_public_acls") || contains(w, "block_public_policy") {
+					found = true
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("expected finding for weakened S3 public access block")
+	}
+}
+
+func TestS3ForceDestroyDelete(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", "aws_s3_bucket.logs"),
+		wantFinding{"will be deleted with force_destroy", SeverityHigh, ""})
+}
+
+func TestS3EmptyBucketDelete(t *testing.T) {
+	if findings := evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", "aws_s3_bucket.assets"); len(findings) != 0 {
+		t.Errorf("expected no findings without force_destroy, got %v", findings)
+	}
+}
+
+func TestS3VersioningSuspended(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", "aws_s3_bucket_versioning.data"),
+		wantFinding{"S3 versioning suspended", SeverityMedium, ""})
+}
+
+func TestS3VersioningNewBucket(t *testing.T) {
+	// A new bucket that starts unversioned is not a regression.
+	for _, address := range []string{"aws_s3_bucket_versioning.scratch", "aws_s3_bucket_versioning.reports"} {
+		if findings := evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", address); len(findings) != 0 {
+			t.Errorf("%s: expected no findings on create, got %v", address, findings)
+		}
+	}
+}
+
+func TestS3EncryptionDowngrade(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", "aws_s3_bucket_server_side_encryption_configuration.data"),
+		wantFinding{"S3 default encryption downgraded", SeverityMedium, ""})
+}
+
+func TestS3EncryptionConfigurationDeleted(t *testing.T) {
+	p := loadTestPlan(t, "s3_data_protection.json")
+	rule := &S3Rule{}
+
+	checkFindings(t, rule.Evaluate(findChange(t, p, "aws_s3_bucket_server_side_encryption_configuration.audit")),
+		wantFinding{"S3 default encryption downgraded", SeverityMedium, "Removing the aws:kms encryption configuration"})
+
+	// Without a KMS configuration the bucket keeps SSE-S3.
+	if findings := rule.Evaluate(findChange(t, p, "aws_s3_bucket_server_side_encryption_configuration.assets")); len(findings) != 0 {
+		t.Errorf("expected no findings when an AES256 configuration is deleted, got %v", findings)
+	}
+}
+
+func TestS3PublicACL(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", "aws_s3_bucket_acl.site"),
+		wantFinding{"S3 bucket ACL grants public access", SeverityHigh, ""})
+}
+
+func TestS3ObjectLockReduced(t *testing.T) {
+	findings := evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", "aws_s3_bucket_object_lock_configuration.audit")
+	checkFindings(t, findings, wantFinding{"S3 object lock retention reduced", SeverityMedium, "COMPLIANCE → GOVERNANCE"})
+	if !anyContains(findings[0].Why, "Default retention: 365 → 30 days") {
+		t.Errorf("expected retention change in why, got %v", findings[0].Why)
+	}
+}
+
+func TestS3OwnershipControlsEnableACLs(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", "aws_s3_bucket_ownership_controls.data"),
+		wantFinding{"S3 ACLs enabled by ownership controls", SeverityMedium, ""})
+}
+
+func TestS3LifecycleNotCheckedPerResource(t *testing.T) {
+	// Lifecycle rules need the bucket's versioning and are checked plan-wide.
+	for _, address := range []string{"aws_s3_bucket_lifecycle_configuration.data", "aws_s3_bucket_lifecycle_configuration.reports"} {
+		if findings := evaluateAddress(t, &S3Rule{}, "s3_data_protection.json", address); len(findings) != 0 {
+			t.Errorf("%s: expected no per-resource findings, got %v", address, findings)
+		}
+	}
+}
+
+func TestS3LifecycleExpiration(t *testing.T) {
+	p := loadTestPlan(t, "s3_data_protection.json")
+	findings := (&S3Rule{}).EvaluatePlan(plan.NewIndex(p))
+
+	// On acme-data "archive" moves from 365 to 400 days and "old" is
+	// disabled; only "tmp" expires sooner, while versioning is suspended.
+	want := map[string]string{
+		`S3 lifecycle rule "tmp" expires current objects on aws_s3_bucket_lifecycle_configuration.data`:       "Versioning is suspended on the bucket: expired objects are permanently deleted",
+		`S3 lifecycle rule "expire" expires current objects on aws_s3_bucket_lifecycle_configuration.reports`: "Versioning is enabled on the bucket",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %v", len(want), len(findings), findings)
+	}
+	for _, f := range findings {
+		effect, ok := want[f.Title]
+		if !ok || f.Severity != SeverityMedium {
+			t.Errorf("unexpected finding: %s %q", severityName(f.Severity), f.Title)
+			continue
+		}
+		if !contains(f.Why[len(f.Why)-2], effect) {
+			t.Errorf("%s: expected why containing %q, got %v", f.Address, effect, f.Why)
+		}
+	}
+}
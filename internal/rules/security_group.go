@@ -3,28 +3,148 @@ package rules
 import (
 	"encoding/json"
 	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/djeeteg007/tf-why/internal/plan"
+	"github.com/djeeteg007/tf-why/internal/util"
 )
 
 var sgTypes = map[string]bool{
-	"aws_security_group_rule": true,
-	"aws_security_group":      true,
+	"aws_security_group_rule":             true,
+	"aws_security_group":                  true,
+	"aws_vpc_security_group_ingress_rule": true,
+	"aws_vpc_security_group_egress_rule":  true,
 }
 
-// Dangerous inbound ports when exposed to 0.0.0.0/0 or ::/0.
-var dangerousPorts = map[int]string{
-	22:   "SSH",
-	3389: "RDP",
-	5432: "PostgreSQL",
-	3306: "MySQL",
-	9200: "Elasticsearch",
-	6379: "Redis",
+// DefaultSensitiveTierNames are security group name globs for database
+// tiers. "db" and "rds" must be a whole word of the name, separated by "-" or
+// "_", so that names like "sandbox", "records" or "app-dbt" do not match.
+func DefaultSensitiveTierNames() []string {
+	return []string{
+		"db", "db-*", "db_*", "*-db", "*_db", "*-db-*", "*_db_*", "*-db_*", "*_db-*",
+		"rds", "rds-*", "rds_*", "*-rds", "*_rds", "*-rds-*", "*_rds_*", "*-rds_*", "*_rds-*",
+		"*database*",
+	}
+}
+
+// DefaultSensitivePorts are services that should not be reachable from the
+// internet. Keys are a port or an inclusive "from-to" range.
+func DefaultSensitivePorts() map[string]string {
+	return map[string]string{
+		"21":        "FTP",
+		"22":        "SSH",
+		"23":        "Telnet",
+		"445":       "SMB",
+		"1433":      "MSSQL",
+		"1521":      "Oracle",
+		"2181":      "ZooKeeper",
+		"2375-2376": "Docker API",
+		"2379-2380": "etcd",
+		"3306":      "MySQL",
+		"3389":      "RDP",
+		"5432":      "PostgreSQL",
+		"5601":      "Kibana",
+		"5672":      "RabbitMQ",
+		"5900":      "VNC",
+		"6379":      "Redis",
+		"6443":      "Kubernetes API",
+		"9042":      "Cassandra",
+		"9092":      "Kafka",
+		"9200":      "Elasticsearch",
+		"10250":     "Kubelet",
+		"11211":     "Memcached",
+		"27017":     "MongoDB",
+	}
+}
+
+// nonPublicPrefixes are address ranges not routable from the internet.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+}
+
+// portService is a parsed sensitive port catalog entry.
+type portService struct {
+	From, To int
+	Name     string
+}
+
+func (p portService) ports() string {
+	if p.From == p.To {
+		return strconv.Itoa(p.From)
+	}
+	return fmt.Sprintf("%d-%d", p.From, p.To)
+}
+
+func (p portService) String() string {
+	return fmt.Sprintf("%s (%s)", p.Name, p.ports())
+}
+
+// ParsePortRange parses "22" or "2375-2376".
+func ParsePortRange(s string) (int, int, error) {
+	fromStr, toStr, isRange := strings.Cut(s, "-")
+	from, err := strconv.Atoi(strings.TrimSpace(fromStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q", s)
+	}
+	to := from
+	if isRange {
+		if to, err = strconv.Atoi(strings.TrimSpace(toStr)); err != nil {
+			return 0, 0, fmt.Errorf("invalid port range %q", s)
+		}
+	}
+	if from < 0 || to > 65535 || from > to {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	return from, to, nil
 }
 
 // SecurityGroupRule detects overly permissive security group configurations.
-type SecurityGroupRule struct{}
+// Each rule entry (an inline ingress block or a standalone rule resource)
+// produces at most one finding listing every sensitive service it exposes.
+type SecurityGroupRule struct {
+	// SensitivePorts maps a port or "from-to" range to a service name.
+	// Entries with an empty name are ignored.
+	SensitivePorts map[string]string
+	// WidePortRange reports publicly open ranges spanning at least this many
+	// ports even when no sensitive port is included. 0 disables the check.
+	WidePortRange int
+	// BroadPrefixIPv4 and BroadPrefixIPv6 are the longest public prefixes
+	// treated as broad (e.g. /8 covers 16M addresses). 0 disables the check.
+	BroadPrefixIPv4 int
+	BroadPrefixIPv6 int
+	// CheckEgress reports egress of all traffic to the internet.
+	CheckEgress bool
+	// SensitiveTierNames are name globs marking security groups of sensitive
+	// tiers (e.g. databases), whose open egress is reported at MEDIUM.
+	SensitiveTierNames []string
+	// SensitiveTierTags maps a tag key to a value glob marking a security
+	// group as a sensitive tier.
+	SensitiveTierTags map[string]string
+}
+
+func (r *SecurityGroupRule) ID() string { return "security-group" }
+
+// sgEntry is one security group rule, normalized across the inline and
+// standalone resource forms.
+type sgEntry struct {
+	Label       string // e.g. "ingress tcp 22 from 0.0.0.0/0" for inline blocks, "" otherwise
+	Egress      bool
+	Protocol    string
+	From, To    int
+	CIDRs       []string
+	PrefixLists []string
+	Groups      []string // referenced security groups, including self
+}
 
 func (r *SecurityGroupRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 	if !sgTypes[rc.Type] {
@@ -41,145 +161,359 @@ func (r *SecurityGroupRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
 		return nil
 	}
 
-	if rc.Type == "aws_security_group_rule" {
-		return r.evaluateRule(afterData, rc)
+	after := sgEntries(rc.Type, afterData)
+	changes := make([]sgChange, len(after))
+	previous := make([]sgEntry, len(after))
+
+	var findings []RuleFinding
+	if beforeData := getBeforeState(rc); beforeData != nil {
+		var removed []sgEntry
+		changes, previous, removed = sgEntryDiff(sgEntries(rc.Type, beforeData), after)
+		findings = append(findings, sgDeltaFinding(after, changes, previous, removed, rc.Address)...)
 	}
 
-	return r.evaluateSecurityGroup(afterData, rc)
+	catalog := r.catalog()
+	tier := r.sensitiveTier(rc.Type, afterData)
+	for i, e := range after {
+		f, ok := r.evaluateEntry(e, catalog, tier, rc.Address)
+		if !ok {
+			continue
+		}
+		// Exposures the plan leaves in place or reduces are informational.
+		switch changes[i] {
+		case sgUnchanged:
+			f.Severity = SeverityLow
+			f.Why = append(f.Why, "Pre-existing: this rule is unchanged by the plan")
+		case sgNarrowed:
+			f.Severity = SeverityLow
+			f.Why = append(f.Why, fmt.Sprintf("Narrowed from %s", previous[i]))
+		case sgWidened:
+			f.Why = append(f.Why, fmt.Sprintf("Widened from %s", previous[i]))
+		}
+		findings = append(findings, f)
+	}
+	return findings
 }
 
-func (r *SecurityGroupRule) evaluateRule(data map[string]interface{}, rc plan.ResourceChange) []RuleFinding {
-	// Only check ingress rules.
-	ruleType, _ := data["type"].(string)
-	if ruleType != "ingress" {
-		return nil
+func (r *SecurityGroupRule) catalog() []portService {
+	var services []portService
+	for ports, name := range r.SensitivePorts {
+		if name == "" {
+			continue
+		}
+		from, to, err := ParsePortRange(ports)
+		if err != nil {
+			continue // rejected by Config.Validate
+		}
+		services = append(services, portService{From: from, To: to, Name: name})
 	}
+	sort.Slice(services, func(i, j int) bool { return services[i].From < services[j].From })
+	return services
+}
 
-	cidrs := extractCIDRs(data)
-	if !hasOpenCIDR(cidrs) {
-		return nil
+func sgEntries(resourceType string, data map[string]interface{}) []sgEntry {
+	switch resourceType {
+	case "aws_security_group":
+		var entries []sgEntry
+		for _, dir := range []string{"ingress", "egress"} {
+			list, _ := data[dir].([]interface{})
+			for _, item := range list {
+				block, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				e := legacyEntry(block)
+				e.Egress = dir == "egress"
+				e.Label = e.label()
+				entries = append(entries, e)
+			}
+		}
+		return entries
+
+	case "aws_security_group_rule":
+		e := legacyEntry(data)
+		e.Egress = data["type"] == "egress"
+		if sg, ok := data["source_security_group_id"].(string); ok && sg != "" {
+			e.Groups = append(e.Groups, sg)
+		}
+		return []sgEntry{e}
+
+	case "aws_vpc_security_group_ingress_rule", "aws_vpc_security_group_egress_rule":
+		e := sgEntry{
+			Egress:   resourceType == "aws_vpc_security_group_egress_rule",
+			Protocol: stringField(data, "ip_protocol"),
+			From:     intFromJSON(data["from_port"]),
+			To:       intFromJSON(data["to_port"]),
+		}
+		for _, key := range []string{"cidr_ipv4", "cidr_ipv6"} {
+			if c := stringField(data, key); c != "" {
+				e.CIDRs = append(e.CIDRs, c)
+			}
+		}
+		if pl := stringField(data, "prefix_list_id"); pl != "" {
+			e.PrefixLists = append(e.PrefixLists, pl)
+		}
+		if sg := stringField(data, "referenced_security_group_id"); sg != "" {
+			e.Groups = append(e.Groups, sg)
+		}
+		return []sgEntry{e}
 	}
+	return nil
+}
 
-	fromPort := intFromJSON(data["from_port"])
-	toPort := intFromJSON(data["to_port"])
-	protocol, _ := data["protocol"].(string)
+// legacyEntry reads the attributes shared by inline blocks and
+// aws_security_group_rule.
+func legacyEntry(data map[string]interface{}) sgEntry {
+	e := sgEntry{
+		Protocol: stringField(data, "protocol"),
+		From:     intFromJSON(data["from_port"]),
+		To:       intFromJSON(data["to_port"]),
+	}
+	for _, key := range []string{"cidr_blocks", "ipv6_cidr_blocks"} {
+		e.CIDRs = append(e.CIDRs, toStringSlice(data[key])...)
+	}
+	e.PrefixLists = toStringSlice(data["prefix_list_ids"])
+	e.Groups = toStringSlice(data["security_groups"])
+	if self, ok := data["self"].(bool); ok && self {
+		e.Groups = append(e.Groups, "self")
+	}
+	return e
+}
 
-	return checkPorts(fromPort, toPort, protocol, cidrs, rc.Address)
+// allPorts reports whether the entry opens every protocol.
+func (e sgEntry) allPorts() bool {
+	return e.Protocol == "-1" || e.Protocol == "all"
 }
 
-func (r *SecurityGroupRule) evaluateSecurityGroup(data map[string]interface{}, rc plan.ResourceChange) []RuleFinding {
-	// Check inline ingress rules.
-	ingressRaw, ok := data["ingress"]
-	if !ok {
-		return nil
+// hasPorts reports whether the protocol has ports (TCP, UDP or all).
+func (e sgEntry) hasPorts() bool {
+	switch strings.ToLower(e.Protocol) {
+	case "-1", "all", "tcp", "udp", "6", "17":
+		return true
 	}
+	return false
+}
 
-	ingressList, ok := ingressRaw.([]interface{})
-	if !ok {
-		return nil
+func (e sgEntry) portRange() string {
+	if e.allPorts() {
+		return "all ports"
+	}
+	if e.From == e.To {
+		return fmt.Sprintf("%s %d", e.Protocol, e.From)
 	}
+	return fmt.Sprintf("%s %d-%d", e.Protocol, e.From, e.To)
+}
 
-	var findings []RuleFinding
-	for _, item := range ingressList {
-		rule, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
+// exposure levels of an entry's sources, most severe first.
+const (
+	exposureNone = iota
+	exposurePrefixList
+	exposureBroad
+	exposureOpen
+)
 
-		cidrs := extractCIDRsFromInline(rule)
-		if !hasOpenCIDR(cidrs) {
-			continue
-		}
+// classifyCIDR returns the exposure of a single CIDR. Unparseable values
+// (e.g. unknown at plan time) are ignored.
+func (r *SecurityGroupRule) classifyCIDR(cidr string) int {
+	p, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return exposureNone
+	}
+	p = p.Masked()
+	if p.Bits() == 0 {
+		return exposureOpen
+	}
 
-		fromPort := intFromJSON(rule["from_port"])
-		toPort := intFromJSON(rule["to_port"])
-		protocol, _ := rule["protocol"].(string)
+	threshold := r.BroadPrefixIPv4
+	if p.Addr().Is6() {
+		threshold = r.BroadPrefixIPv6
+	}
+	if threshold == 0 || p.Bits() > threshold {
+		return exposureNone
+	}
+	for _, np := range nonPublicPrefixes {
+		if np.Bits() <= p.Bits() && np.Contains(p.Addr()) {
+			return exposureNone
+		}
+	}
+	return exposureBroad
+}
 
-		findings = append(findings, checkPorts(fromPort, toPort, protocol, cidrs, rc.Address)...)
+// evaluateEntry reports the exposure of one entry. tier explains why the
+// group is a sensitive tier, or is empty.
+func (r *SecurityGroupRule) evaluateEntry(e sgEntry, catalog []portService, tier, address string) (RuleFinding, bool) {
+	if !e.hasPorts() {
+		return RuleFinding{}, false
 	}
 
-	return findings
-}
+	exposure := exposureNone
+	var sources []string
+	for _, c := range e.CIDRs {
+		if level := r.classifyCIDR(c); level != exposureNone {
+			if level > exposure {
+				exposure = level
+			}
+			sources = append(sources, c)
+		}
+	}
+	if exposure == exposureNone && len(e.PrefixLists) > 0 {
+		exposure = exposurePrefixList
+		sources = e.PrefixLists
+	}
+	if exposure == exposureNone {
+		return RuleFinding{}, false
+	}
 
-func checkPorts(fromPort, toPort int, protocol string, cidrs []string, address string) []RuleFinding {
-	// If protocol is -1 (all), all ports are open.
-	allPorts := protocol == "-1" || protocol == "all"
+	span := e.To - e.From + 1
+	wide := e.allPorts() || (r.WidePortRange > 0 && span >= r.WidePortRange)
 
-	var findings []RuleFinding
-	openCIDRs := filterOpenCIDRs(cidrs)
-	cidrStr := strings.Join(openCIDRs, ", ")
-
-	for port, svcName := range dangerousPorts {
-		if allPorts || (port >= fromPort && port <= toPort) {
-			findings = append(findings, RuleFinding{
-				Severity: SeverityHigh,
-				Tags:     []string{"security"},
-				Title:    fmt.Sprintf("%s port %d (%s) open to the internet on %s", svcName, port, protocol, address),
+	if e.Egress {
+		if tier != "" && exposure == exposureOpen && wide {
+			why := []string{fmt.Sprintf("%s allow outbound %s", strings.Join(sources, ", "), e.portRange())}
+			if !e.allPorts() {
+				why = append(why, fmt.Sprintf("Port range spans %d ports", span))
+			}
+			return RuleFinding{
+				Severity: SeverityMedium,
+				Tags:     []string{"network", "security"},
+				Title:    withLabel(fmt.Sprintf("Unrestricted egress from a sensitive tier on %s", address), e.Label),
 				Address:  address,
-				Why: []string{
-					fmt.Sprintf("CIDR %s allows inbound traffic on port %d (%s)", cidrStr, port, svcName),
-					fmt.Sprintf("Protocol: %s, Port range: %d-%d", protocol, fromPort, toPort),
-				},
+				Why: append(why,
+					"Sensitive tier: "+tier,
+					"A compromised host in this tier can send data anywhere on the internet",
+				),
 				Recommendations: []string{
-					fmt.Sprintf("Restrict CIDR to specific IP ranges instead of %s", cidrStr),
-					fmt.Sprintf("Use a bastion host or VPN for %s access", svcName),
+					"Restrict egress to the VPC, VPC endpoints or the specific services the tier calls",
 				},
-			})
+			}, true
+		}
+		if !r.CheckEgress || exposure != exposureOpen || !e.allPorts() {
+			return RuleFinding{}, false
 		}
+		return RuleFinding{
+			Severity: SeverityLow,
+			Tags:     []string{"network"},
+			Title:    withLabel(fmt.Sprintf("Unrestricted egress to the internet on %s", address), e.Label),
+			Address:  address,
+			Why: []string{
+				fmt.Sprintf("%s allow outbound traffic on all protocols and ports", strings.Join(sources, ", ")),
+			},
+			Recommendations: []string{
+				"Restrict egress to the destinations and ports the workload needs",
+			},
+		}, true
 	}
 
-	return findings
-}
+	var exposed []portService
+	for _, svc := range catalog {
+		if e.allPorts() || (svc.From <= e.To && svc.To >= e.From) {
+			exposed = append(exposed, svc)
+		}
+	}
+	if len(exposed) == 0 && !wide {
+		return RuleFinding{}, false
+	}
 
-func extractCIDRs(data map[string]interface{}) []string {
-	var cidrs []string
-	for _, key := range []string{"cidr_blocks", "ipv6_cidr_blocks"} {
-		if raw, ok := data[key]; ok {
-			if list, ok := raw.([]interface{}); ok {
-				for _, item := range list {
-					if s, ok := item.(string); ok {
-						cidrs = append(cidrs, s)
-					}
-				}
-			}
+	why := []string{fmt.Sprintf("%s allow inbound %s", strings.Join(sources, ", "), e.portRange())}
+	if len(exposed) > 0 {
+		names := make([]string, len(exposed))
+		for i, svc := range exposed {
+			names[i] = svc.String()
 		}
+		why = append(why, "Exposes "+strings.Join(names, ", "))
+	}
+	if e.allPorts() {
+		why = append(why, "All protocols and ports are open")
+	} else if wide {
+		why = append(why, fmt.Sprintf("Port range spans %d ports", span))
+	}
+	if len(e.Groups) > 0 {
+		why = append(why, fmt.Sprintf("Also allows security groups: %s", strings.Join(e.Groups, ", ")))
 	}
-	return cidrs
-}
 
-func extractCIDRsFromInline(rule map[string]interface{}) []string {
-	var cidrs []string
-	for _, key := range []string{"cidr_blocks", "ipv6_cidr_blocks"} {
-		if raw, ok := rule[key]; ok {
-			if list, ok := raw.([]interface{}); ok {
-				for _, item := range list {
-					if s, ok := item.(string); ok {
-						cidrs = append(cidrs, s)
-					}
-				}
-			}
+	subject := "Wide port range"
+	switch {
+	case e.allPorts():
+		subject = "All ports"
+	case len(exposed) == 1:
+		subject = fmt.Sprintf("%s port %s", exposed[0].Name, exposed[0].ports())
+	case len(exposed) > 1:
+		subject = fmt.Sprintf("%d sensitive ports", len(exposed))
+	}
+
+	f := RuleFinding{Tags: []string{"security"}, Address: address, Why: why}
+	switch exposure {
+	case exposureOpen:
+		f.Severity = SeverityHigh
+		f.Title = fmt.Sprintf("%s open to the internet on %s", subject, address)
+		f.Recommendations = []string{
+			"Restrict CIDR to specific IP ranges instead of " + strings.Join(sources, ", "),
+			"Use a bastion host, VPN or SSM Session Manager for administrative access",
+		}
+	case exposureBroad:
+		f.Severity = SeverityMedium
+		f.Title = fmt.Sprintf("%s open to a broad public range on %s", subject, address)
+		f.Why = append(f.Why, fmt.Sprintf("Broad public range: %s", strings.Join(sources, ", ")))
+		f.Recommendations = []string{
+			"Narrow the CIDR to the addresses that need access",
+		}
+	case exposurePrefixList:
+		f.Severity = SeverityLow
+		f.Title = fmt.Sprintf("%s open to a prefix list on %s", subject, address)
+		f.Why = append(f.Why, "Prefix list contents are not visible in the plan")
+		f.Recommendations = []string{
+			"Confirm the prefix list only contains trusted ranges",
 		}
 	}
-	return cidrs
+	f.Title = withLabel(f.Title, e.Label)
+	return f, true
 }
 
-func hasOpenCIDR(cidrs []string) bool {
-	for _, c := range cidrs {
-		if c == "0.0.0.0/0" || c == "::/0" {
-			return true
+// sensitiveTier returns why an aws_security_group belongs to a sensitive
+// tier, by name or tags, or "" if it does not. Standalone rule resources do
+// not carry the group's name or tags.
+func (r *SecurityGroupRule) sensitiveTier(resourceType string, data map[string]interface{}) string {
+	if resourceType != "aws_security_group" {
+		return ""
+	}
+	name := stringField(data, "name")
+	if name == "" {
+		name = stringField(data, "name_prefix")
+	}
+	for _, p := range r.SensitiveTierNames {
+		if name != "" && util.MatchGlob(p, strings.ToLower(name)) {
+			return fmt.Sprintf("name %q matches %q", name, p)
 		}
 	}
-	return false
+	tags, _ := data["tags"].(map[string]interface{})
+	keys := make([]string, 0, len(r.SensitiveTierTags))
+	for k := range r.SensitiveTierTags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if r.SensitiveTierTags[k] == "" {
+			continue
+		}
+		if v, ok := tags[k].(string); ok && util.MatchGlob(r.SensitiveTierTags[k], v) {
+			return fmt.Sprintf("tag %s=%s", k, v)
+		}
+	}
+	return ""
 }
 
-func filterOpenCIDRs(cidrs []string) []string {
-	var result []string
-	for _, c := range cidrs {
-		if c == "0.0.0.0/0" || c == "::/0" {
-			result = append(result, c)
-		}
+// withLabel appends an inline block label to a title so that entries of the
+// same security group get distinct findings.
+func withLabel(title, label string) string {
+	if label == "" {
+		return title
 	}
-	return result
+	return title + " (" + label + ")"
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
 }
 
 func intFromJSON(v interface{}) int {
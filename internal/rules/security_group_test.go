@@ -0,0 +1,222 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSGOpenSSH(t *testing.T) {
+	findings := evaluateAll(t, "sg_open_ssh.json")
+	found := false
+	for _, f := range findings {
+		if f.Severity == SeverityHigh && containsTag(f.Tags, "security") {
+			for _, w := range f.Why {
+				if contains(w, "0.0.0.0/0") && contains(w, "22") {
+					found = true
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("expected HIGH security finding for SSH open to internet")
+	}
+}
+
+func TestSGInlineAllPorts(t *testing.T) {
+	findings := evaluateAll(t, "sg_inline_multi.json")
+	if len(findings) == 0 {
+		t.Fatal("expected findings for wide-open security group")
+	}
+	// Should find all dangerous ports
+	ports := map[string]bool{}
+	for _, f := range findings {
+		for _, w := range f.Why {
+			for _, svc := range []string{"SSH", "RDP", "PostgreSQL", "MySQL", "Elasticsearch", "Redis"} {
+				if contains(w, svc) {
+					ports[svc] = true
+				}
+			}
+		}
+	}
+	if len(ports) < 6 {
+		t.Errorf("expected all 6 dangerous ports flagged, got %d: %v", len(ports), ports)
+	}
+}
+
+func TestSGCollapsedPerEntry(t *testing.T) {
+	findings := evaluateAll(t, "sg_inline_multi.json")
+	if len(findings) != 1 {
+		t.Fatalf("expected one finding for a single all-ports entry, got %d", len(findings))
+	}
+	if !contains(findings[0].Title, "All ports open to the internet") {
+		t.Errorf("unexpected title %q", findings[0].Title)
+	}
+}
+
+// catalogRule returns the security group rule with the default catalog.
+func catalogRule() *SecurityGroupRule {
+	cfg := DefaultConfig()
+	return &SecurityGroupRule{
+		SensitivePorts:  cfg.SGSensitivePorts,
+		WidePortRange:   cfg.SGWidePortRange,
+		BroadPrefixIPv4: cfg.SGBroadPrefixIPv4,
+		BroadPrefixIPv6: cfg.SGBroadPrefixIPv6,
+	}
+}
+
+func TestSGCatalogSensitivePort(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, catalogRule(), "sg_catalog.json", "aws_vpc_security_group_ingress_rule.mongo"),
+		wantFinding{"MongoDB port 27017 open to the internet", SeverityHigh, ""})
+}
+
+func TestSGCatalogPortRange(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, catalogRule(), "sg_catalog.json", "aws_security_group_rule.kafka_es"),
+		wantFinding{"3 sensitive ports open to the internet", SeverityHigh, ""})
+}
+
+func TestSGCatalogWidePortRange(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, catalogRule(), "sg_catalog.json", "aws_security_group_rule.node_ports"),
+		wantFinding{"Wide port range open to the internet", SeverityHigh, ""})
+}
+
+func TestSGCatalogBroadRange(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, catalogRule(), "sg_catalog.json", "aws_vpc_security_group_ingress_rule.broad"),
+		wantFinding{"SSH port 22 open to a broad public range", SeverityMedium, ""})
+}
+
+func TestSGCatalogPrivateRange(t *testing.T) {
+	findings := evaluateAddress(t, catalogRule(), "sg_catalog.json", "aws_vpc_security_group_ingress_rule.private")
+	if len(findings) != 0 {
+		t.Errorf("expected no findings for a private range, got %v", findings)
+	}
+}
+
+func TestSGCatalogPrefixList(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, catalogRule(), "sg_catalog.json", "aws_vpc_security_group_ingress_rule.prefix_list"),
+		wantFinding{"Kubernetes API port 6443 open to a prefix list", SeverityLow, ""})
+}
+
+func TestSGCatalogInlineEntry(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, catalogRule(), "sg_catalog.json", "aws_security_group.multi"),
+		wantFinding{"SSH port 22 open to the internet on aws_security_group.multi (ingress tcp 22 from 0.0.0.0/0, self)", SeverityHigh, ""})
+}
+
+func TestSGInlineLabelIgnoresPosition(t *testing.T) {
+	rule := catalogRule()
+	rc := findChange(t, loadTestPlan(t, "sg_catalog.json"), "aws_security_group.multi")
+	want := rule.Evaluate(rc)
+
+	// A block added before the exposed one does not change its title.
+	var after map[string]interface{}
+	if err := json.Unmarshal(rc.Change.After, &after); err != nil {
+		t.Fatal(err)
+	}
+	http := map[string]interface{}{"protocol": "tcp", "from_port": 80, "to_port": 80, "cidr_blocks": []interface{}{"10.0.0.0/8"}}
+	after["ingress"] = append([]interface{}{http}, after["ingress"].([]interface{})...)
+	rc.Change.After, _ = json.Marshal(after)
+
+	got := rule.Evaluate(rc)
+	if len(got) != len(want) || got[0].Title != want[0].Title {
+		t.Errorf("expected titles %v, got %v", want, got)
+	}
+}
+
+func TestSGCatalogCustomPorts(t *testing.T) {
+	// Entries with an empty name are ignored.
+	rule := catalogRule()
+	rule.SensitivePorts = map[string]string{"443": "HTTPS admin", "22": ""}
+	checkFindings(t, evaluateAddress(t, rule, "sg_catalog.json", "aws_security_group.multi"),
+		wantFinding{"HTTPS admin port 443", SeverityHigh, ""})
+}
+
+func TestSGEgress(t *testing.T) {
+	// Egress is only checked when enabled.
+	rule := catalogRule()
+	if findings := evaluateAddress(t, rule, "sg_catalog.json", "aws_vpc_security_group_egress_rule.all"); len(findings) != 0 {
+		t.Errorf("expected no egress findings by default, got %v", findings)
+	}
+	rule.CheckEgress = true
+	checkFindings(t, evaluateAddress(t, rule, "sg_catalog.json", "aws_vpc_security_group_egress_rule.all"),
+		wantFinding{"Unrestricted egress", SeverityLow, ""})
+}
+
+func TestSGUpdatePreexistingExposure(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, configuredRule(t, "security-group"), "sg_update.json", "aws_security_group.web"),
+		wantFinding{"SSH port 22 open to the internet", SeverityLow, "Pre-existing"})
+}
+
+func TestSGUpdateAddedRule(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, configuredRule(t, "security-group"), "sg_update.json", "aws_security_group.api"),
+		wantFinding{"Security group rules changed", SeverityLow, "+ ingress tcp 22 from 0.0.0.0/0"},
+		wantFinding{"SSH port 22 open to the internet", SeverityHigh, ""})
+}
+
+func TestSGUpdateWidenedRule(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, configuredRule(t, "security-group"), "sg_update.json", "aws_security_group_rule.db"),
+		wantFinding{"Security group rules changed", SeverityLow, "~ widened"},
+		wantFinding{"PostgreSQL port 5432 open to the internet", SeverityHigh, "Widened from ingress tcp 5432 from 203.0.113.0/24"})
+}
+
+func TestSGUpdateNarrowedRule(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, configuredRule(t, "security-group"), "sg_update.json", "aws_vpc_security_group_ingress_rule.admin"),
+		wantFinding{"Security group rules changed", SeverityLow, "~ narrowed"},
+		wantFinding{"SSH port 22 open to the internet", SeverityLow, "Narrowed from"})
+}
+
+func TestSGUpdateRemovedRule(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, configuredRule(t, "security-group"), "sg_update.json", "aws_security_group.legacy"),
+		wantFinding{"Security group rules changed", SeverityLow, "- ingress tcp 3389 from 0.0.0.0/0"})
+}
+
+func TestSGSensitiveTierEgress(t *testing.T) {
+	p := loadTestPlan(t, "nacl_exposure.json")
+	rule := configuredRule(t, "security-group")
+
+	want := map[string]string{
+		"aws_security_group.orders_db": `name "orders-db" matches "*-db"`,
+		"aws_security_group.cache":     "tag Tier=data",
+		"aws_security_group.web":       "",
+	}
+	for _, rc := range p.ResourceChanges {
+		tier, ok := want[rc.Address]
+		if !ok {
+			continue
+		}
+		findings := rule.Evaluate(rc)
+		if tier == "" {
+			if len(findings) != 0 {
+				t.Errorf("%s: expected no findings without sg_check_egress, got %v", rc.Address, findings)
+			}
+			continue
+		}
+		if len(findings) != 1 || findings[0].Severity != SeverityMedium || !contains(findings[0].Title, "Unrestricted egress from a sensitive tier") {
+			t.Fatalf("%s: expected one MEDIUM sensitive tier egress finding, got %v", rc.Address, findings)
+		}
+		if !containsStr(findings[0].Why, "Sensitive tier: "+tier) {
+			t.Errorf("%s: expected tier reason %q, got %v", rc.Address, tier, findings[0].Why)
+		}
+	}
+}
+
+func TestSGSensitiveTierDefaultNames(t *testing.T) {
+	rule := &SecurityGroupRule{SensitiveTierNames: DefaultSensitiveTierNames()}
+	for name, want := range map[string]bool{
+		"db":            true,
+		"orders-db":     true,
+		"db_primary":    true,
+		"app-rds-proxy": true,
+		"main-database": true,
+		"sandbox":       false,
+		"feedback":      false,
+		"records-api":   false,
+		"dashboard-web": false,
+		"app-dbt":       false,
+		"ci-dbg":        false,
+		"x_rdsproxy":    false,
+	} {
+		got := rule.sensitiveTier("aws_security_group", map[string]interface{}{"name": name}) != ""
+		if got != want {
+			t.Errorf("%q: expected sensitive tier %v, got %v", name, want, got)
+		}
+	}
+}
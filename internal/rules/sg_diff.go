@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sgChange classifies an after-state entry against the before state.
+type sgChange int
+
+const (
+	sgAdded     sgChange = iota // no comparable entry before
+	sgUnchanged                 // identical entry existed before
+	sgWidened                   // replaces an entry with a subset of its ports and sources
+	sgNarrowed                  // replaces an entry with a superset of its ports and sources
+)
+
+// normProtocol maps protocol numbers and aliases to one spelling.
+func normProtocol(p string) string {
+	switch strings.ToLower(p) {
+	case "-1", "all":
+		return "all"
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	case "1":
+		return "icmp"
+	case "58":
+		return "icmpv6"
+	}
+	return strings.ToLower(p)
+}
+
+// sources returns the entry's CIDRs, prefix lists and referenced groups.
+func (e sgEntry) sources() []string {
+	var all []string
+	all = append(all, e.CIDRs...)
+	all = append(all, e.PrefixLists...)
+	all = append(all, e.Groups...)
+	return all
+}
+
+func (e sgEntry) direction() string {
+	if e.Egress {
+		return "egress"
+	}
+	return "ingress"
+}
+
+// ports returns the port range, treating "all" protocols as 0-65535.
+func (e sgEntry) ports() (int, int) {
+	if normProtocol(e.Protocol) == "all" {
+		return 0, 65535
+	}
+	return e.From, e.To
+}
+
+// key identifies an entry by what it allows, ignoring its description and
+// position in the list.
+func (e sgEntry) key() string {
+	from, to := e.ports()
+	return fmt.Sprintf("%s|%s|%d-%d|%s", e.direction(), normProtocol(e.Protocol), from, to,
+		strings.Join(sortedCopy(e.sources()), ","))
+}
+
+// label names an inline entry by what it allows rather than by its position,
+// so that adding or removing other blocks of the group keeps its findings'
+// titles, and so their fingerprints, unchanged.
+func (e sgEntry) label() string {
+	prep := "from"
+	if e.Egress {
+		prep = "to"
+	}
+	return fmt.Sprintf("%s %s %s %s", e.direction(), e.portRange(), prep, strings.Join(sortedCopy(e.sources()), ", "))
+}
+
+func (e sgEntry) String() string {
+	prep := "from"
+	if e.Egress {
+		prep = "to"
+	}
+	return fmt.Sprintf("%s %s %s %s", e.direction(), e.portRange(), prep, strings.Join(e.sources(), ", "))
+}
+
+// covers reports whether e allows everything o allows: the same direction
+// and protocol, a port range containing o's, and a superset of its sources.
+func (e sgEntry) covers(o sgEntry) bool {
+	ep, op := normProtocol(e.Protocol), normProtocol(o.Protocol)
+	if e.direction() != o.direction() || (ep != op && ep != "all") {
+		return false
+	}
+	eFrom, eTo := e.ports()
+	oFrom, oTo := o.ports()
+	if eFrom > oFrom || eTo < oTo {
+		return false
+	}
+	have := make(map[string]bool)
+	for _, s := range e.sources() {
+		have[s] = true
+	}
+	for _, s := range o.sources() {
+		if !have[s] && !have["0.0.0.0/0"] && !have["::/0"] {
+			return false
+		}
+	}
+	return true
+}
+
+// sgEntryDiff classifies after entries against before entries. For widened
+// and narrowed entries it also returns the entry they replace. Before
+// entries with no counterpart are returned as removed.
+func sgEntryDiff(before, after []sgEntry) (changes []sgChange, previous []sgEntry, removed []sgEntry) {
+	beforeKeys := make(map[string]bool, len(before))
+	for _, b := range before {
+		beforeKeys[b.key()] = true
+	}
+	afterKeys := make(map[string]bool, len(after))
+	for _, a := range after {
+		afterKeys[a.key()] = true
+	}
+
+	var gone []sgEntry
+	for _, b := range before {
+		if !afterKeys[b.key()] {
+			gone = append(gone, b)
+		}
+	}
+	matched := make([]bool, len(gone))
+
+	changes = make([]sgChange, len(after))
+	previous = make([]sgEntry, len(after))
+	for i, a := range after {
+		if beforeKeys[a.key()] {
+			changes[i] = sgUnchanged
+			continue
+		}
+		changes[i] = sgAdded
+		for j, b := range gone {
+			if matched[j] {
+				continue
+			}
+			switch {
+			case a.covers(b):
+				changes[i], previous[i] = sgWidened, b
+			case b.covers(a):
+				changes[i], previous[i] = sgNarrowed, b
+			default:
+				continue
+			}
+			matched[j] = true
+			break
+		}
+	}
+
+	for j, b := range gone {
+		if !matched[j] {
+			removed = append(removed, b)
+		}
+	}
+	return changes, previous, removed
+}
+
+// sgDeltaFinding lists rule entries added, widened, narrowed or removed by
+// an update. It returns nil when the entry sets are equal.
+func sgDeltaFinding(after []sgEntry, changes []sgChange, previous, removed []sgEntry, address string) []RuleFinding {
+	var added, modified, lines []string
+	for i, a := range after {
+		switch changes[i] {
+		case sgAdded:
+			added = append(added, "+ "+a.String())
+		case sgWidened:
+			modified = append(modified, fmt.Sprintf("~ widened: %s (was %s)", a, previous[i]))
+		case sgNarrowed:
+			modified = append(modified, fmt.Sprintf("~ narrowed: %s (was %s)", a, previous[i]))
+		}
+	}
+	lines = append(added, modified...)
+	for _, b := range removed {
+		lines = append(lines, "- "+b.String())
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return []RuleFinding{{
+		Severity: SeverityLow,
+		Tags:     []string{"network"},
+		Title:    fmt.Sprintf("Security group rules changed on %s", address),
+		Address:  address,
+		Why:      lines,
+		Recommendations: []string{
+			"Review added and widened rules (+, ~) for least-privilege",
+			"Confirm removed rules (-) are no longer needed",
+		},
+	}}
+}
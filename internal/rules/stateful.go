@@ -0,0 +1,109 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StatefulResource describes a resource type that holds data, whose delete
+// or replace loses that data.
+type StatefulResource struct {
+	// SnapshotAttributes are attributes of the current state that say whether
+	// a final snapshot or backup is kept. Their values are shown in findings.
+	// "block.attr" reads an attribute of the first nested block.
+	SnapshotAttributes []string `json:"snapshot_attributes,omitempty"`
+	// Recommendations are type-specific advice added to findings.
+	Recommendations []string `json:"recommendations,omitempty"`
+}
+
+// DefaultStatefulResources is the built-in catalog of stateful resource
+// types. The rds and dynamodb rules report replaces of their types, and the
+// catalog covers their deletes. S3 buckets are not listed: deleting a
+// non-empty bucket fails unless force_destroy is set, which the s3 rule
+// reports.
+func DefaultStatefulResources() map[string]*StatefulResource {
+	finalSnapshot := []string{"skip_final_snapshot", "final_snapshot_identifier"}
+	finalSnapshotRec := "Set skip_final_snapshot = false and a final_snapshot_identifier, and apply that first"
+	rdsSnapshot := append(append([]string(nil), finalSnapshot...), "deletion_protection")
+	return map[string]*StatefulResource{
+		"aws_db_instance": {
+			SnapshotAttributes: rdsSnapshot,
+			Recommendations:    []string{finalSnapshotRec},
+		},
+		"aws_rds_cluster": {
+			SnapshotAttributes: rdsSnapshot,
+			Recommendations:    []string{finalSnapshotRec},
+		},
+		"aws_dynamodb_table": {
+			SnapshotAttributes: []string{"deletion_protection_enabled", "point_in_time_recovery.enabled"},
+			Recommendations: []string{
+				"Take an on-demand backup or export the table to S3 before deleting it; with point-in-time recovery enabled, AWS keeps a system backup for 35 days",
+			},
+		},
+		"aws_efs_file_system": {
+			Recommendations: []string{"Confirm a recent AWS Backup recovery point of the file system exists"},
+		},
+		"aws_ebs_volume": {
+			SnapshotAttributes: []string{"final_snapshot"},
+			Recommendations:    []string{"Set final_snapshot = true, or snapshot the volume before applying"},
+		},
+		"aws_elasticache_replication_group": {
+			SnapshotAttributes: []string{"final_snapshot_identifier", "snapshot_retention_limit"},
+			Recommendations:    []string{"Set final_snapshot_identifier so a snapshot is taken before deletion"},
+		},
+		"aws_elasticache_cluster": {
+			SnapshotAttributes: []string{"final_snapshot_identifier", "snapshot_retention_limit"},
+			Recommendations:    []string{"Set final_snapshot_identifier so a snapshot is taken before deletion"},
+		},
+		"aws_opensearch_domain": {
+			Recommendations: []string{"Take a manual snapshot to an S3 repository; automated snapshots are deleted with the domain"},
+		},
+		"aws_elasticsearch_domain": {
+			Recommendations: []string{"Take a manual snapshot to an S3 repository; automated snapshots are deleted with the domain"},
+		},
+		"aws_redshift_cluster": {
+			SnapshotAttributes: finalSnapshot,
+			Recommendations:    []string{finalSnapshotRec},
+		},
+		"aws_docdb_cluster": {
+			SnapshotAttributes: finalSnapshot,
+			Recommendations:    []string{finalSnapshotRec},
+		},
+		"aws_neptune_cluster": {
+			SnapshotAttributes: finalSnapshot,
+			Recommendations:    []string{finalSnapshotRec},
+		},
+		"aws_msk_cluster": {
+			Recommendations: []string{"Confirm consumers have processed every topic, or mirror the topics to another cluster first"},
+		},
+		"aws_sqs_queue": {
+			SnapshotAttributes: []string{"message_retention_seconds"},
+			Recommendations:    []string{"Drain the queue (ApproximateNumberOfMessagesVisible = 0) before deleting it"},
+		},
+		"aws_kinesis_stream": {
+			SnapshotAttributes: []string{"retention_period"},
+			Recommendations:    []string{"Confirm every consumer has processed the retained records"},
+		},
+	}
+}
+
+// snapshotState describes the snapshot attributes of a resource's current
+// state, e.g. `skip_final_snapshot = true`.
+func (s *StatefulResource) snapshotState(before map[string]interface{}) []string {
+	var why []string
+	for _, attr := range s.SnapshotAttributes {
+		data, key := before, attr
+		if block, field, nested := strings.Cut(attr, "."); nested {
+			data, key = firstBlock(before, block), field
+		}
+		v, ok := data[key]
+		if str, isStr := v.(string); !ok || v == nil || (isStr && str == "") {
+			why = append(why, fmt.Sprintf("%s = (not set)", attr))
+			continue
+		}
+		b, _ := json.Marshal(v)
+		why = append(why, fmt.Sprintf("%s = %s", attr, b))
+	}
+	return why
+}
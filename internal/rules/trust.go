@@ -0,0 +1,288 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/djeeteg007/tf-why/internal/plan"
+)
+
+// TrustPolicyRule checks who can assume an IAM role, from the role's
+// assume_role_policy.
+type TrustPolicyRule struct {
+	// TrustedAccounts are account IDs that may assume roles without a
+	// third-party finding or an sts:ExternalId condition.
+	TrustedAccounts []string
+	// OwnerAccounts are the accounts owning resources in the plan, which
+	// roles may also trust.
+	OwnerAccounts []string
+	// ReportPreexisting keeps risks already present before an update,
+	// downgraded to LOW, instead of dropping them.
+	ReportPreexisting bool
+}
+
+func (r *TrustPolicyRule) ID() string { return "trust-policy" }
+
+func (r *TrustPolicyRule) Evaluate(rc plan.ResourceChange) []RuleFinding {
+	if rc.Type != "aws_iam_role" {
+		return nil
+	}
+
+	action := rc.Change.Actions.ActionType()
+	if action == plan.ActionNoop || action == plan.ActionRead || action == plan.ActionDelete {
+		return nil
+	}
+
+	afterData := getAfterState(rc)
+	if afterData == nil {
+		return nil
+	}
+	policyJSON, _ := afterData["assume_role_policy"].(string)
+	if policyJSON == "" {
+		return nil
+	}
+
+	beforeData := getBeforeState(rc)
+	known := knownAccounts(r.TrustedAccounts, r.OwnerAccounts, afterData, beforeData)
+	risks := trustRisks(policyJSON, rc.Address, known)
+
+	var findings []RuleFinding
+	if beforeData != nil {
+		if beforeJSON, _ := beforeData["assume_role_policy"].(string); beforeJSON != "" {
+			risks = newRisks(risks, trustRisks(beforeJSON, rc.Address, known), r.ReportPreexisting)
+			for _, f := range policyDeltaFinding(beforeJSON, policyJSON, rc.Address) {
+				f.Title += " (assume_role_policy)"
+				findings = append(findings, f)
+			}
+		}
+	}
+	return append(findings, riskFindings(risks)...)
+}
+
+// isAssumeAction reports whether an action pattern covers one of the
+// sts:AssumeRole* actions.
+func isAssumeAction(a string) bool {
+	l := strings.ToLower(a)
+	return l == "*" || l == "sts:*" || strings.HasPrefix(l, "sts:assumerole")
+}
+
+// trustRisks finds risks in a role trust policy. known holds the accounts a
+// role may trust without a third-party finding; with nil, other accounts are
+// reported at LOW.
+func trustRisks(policyJSON, address string, known map[string]bool) []policyRisk {
+	var doc policyDocument
+	if err := json.Unmarshal([]byte(policyJSON), &doc); err != nil {
+		return nil
+	}
+
+	var risks []policyRisk
+	for i, stmt := range doc.Statement {
+		if !stmt.isAllow() {
+			continue
+		}
+		var assumes, webIdentity bool
+		for _, a := range toStringSlice(stmt.Action) {
+			if isAssumeAction(a) {
+				assumes = true
+			}
+			l := strings.ToLower(a)
+			if l == "*" || l == "sts:*" || l == "sts:assumerolewithwebidentity" {
+				webIdentity = true
+			}
+		}
+		if !assumes {
+			continue
+		}
+
+		for _, p := range stmt.awsPrincipals() {
+			if p == "*" {
+				if stmt.hasRestrictingCondition() {
+					continue
+				}
+				risks = append(risks, policyRisk{
+					Key: "trust-public",
+					Finding: RuleFinding{
+						Severity: SeverityHigh,
+						Tags:     []string{"security"},
+						Title:    fmt.Sprintf("Role %s can be assumed by any AWS principal", address),
+						Address:  address,
+						Why: []string{
+							fmt.Sprintf("assume_role_policy Statement[%d].Principal is \"*\" with no aws:PrincipalOrgID, aws:PrincipalAccount or similar condition", i),
+							"Any AWS account, including attackers', can obtain this role's credentials",
+						},
+						Recommendations: []string{
+							"Restrict Principal to specific account or role ARNs",
+							"Or add an aws:PrincipalOrgID condition",
+						},
+					},
+				})
+				continue
+			}
+
+			acct := accountFromPrincipal(p)
+			if acct == "" || known[acct] {
+				continue
+			}
+			sev, why := SeverityMedium, "Account owns no resource in the plan and is not listed in rules.trusted_accounts"
+			if known == nil {
+				sev, why = SeverityLow, unknownOwnerWhy
+			}
+			risks = append(risks, policyRisk{
+				Key: "trust-account:" + acct,
+				Finding: RuleFinding{
+					Severity: sev,
+					Tags:     []string{"security"},
+					Title:    fmt.Sprintf("Role %s trusts third-party account %s", address, acct),
+					Address:  address,
+					Why: []string{
+						fmt.Sprintf("assume_role_policy Statement[%d] lets %s assume this role", i, p),
+						why,
+					},
+					Recommendations: []string{
+						"Confirm the external account is expected to assume this role",
+						"Add the account to rules.trusted_accounts in the tf-why config if it is trusted",
+					},
+				},
+			})
+			if !hasConditionKey(stmt, "sts:externalid") {
+				risks = append(risks, policyRisk{
+					Key: "trust-external-id:" + acct,
+					Finding: RuleFinding{
+						Severity: sev,
+						Tags:     []string{"security"},
+						Title:    fmt.Sprintf("Cross-account trust without sts:ExternalId on %s", address),
+						Address:  address,
+						Why: []string{
+							fmt.Sprintf("assume_role_policy Statement[%d] trusts account %s with no sts:ExternalId condition", i, acct),
+							"Without an external ID a third party can be tricked into acting on this role (confused deputy)",
+						},
+						Recommendations: []string{
+							"Add a StringEquals condition on sts:ExternalId with the value agreed with the third party",
+						},
+					},
+				})
+			}
+		}
+
+		if webIdentity {
+			risks = append(risks, webIdentityRisks(stmt, i, address)...)
+		}
+	}
+	return risks
+}
+
+// webIdentityRisks flags OIDC federation trusts that do not pin the token
+// audience and subject. Without a subject condition any identity of the
+// provider (e.g. any GitHub Actions workflow of any repository) can assume
+// the role.
+func webIdentityRisks(stmt policyStatement, index int, address string) []policyRisk {
+	p, ok := stmt.Principal.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var risks []policyRisk
+	for _, federated := range toStringSlice(p["Federated"]) {
+		provider := federated
+		if i := strings.Index(federated, "oidc-provider/"); i >= 0 {
+			provider = federated[i+len("oidc-provider/"):]
+		}
+		if strings.Contains(federated, ":saml-provider/") {
+			continue
+		}
+
+		// Cognito identity pools pin the pool through aud; they have no
+		// meaningful per-repository subject.
+		needsSubject := provider != "cognito-identity.amazonaws.com"
+		sub := conditionValues(stmt, provider+":sub")
+		if needsSubject && (len(sub) == 0 || anyBroadSubject(sub)) {
+			subWhy := fmt.Sprintf("assume_role_policy Statement[%d] allows sts:AssumeRoleWithWebIdentity from %s without a %s:sub condition", index, provider, provider)
+			if len(sub) > 0 {
+				subWhy = fmt.Sprintf("assume_role_policy Statement[%d] allows sts:AssumeRoleWithWebIdentity from %s with %s:sub %s, which matches any repository or workload", index, provider, provider, strings.Join(sub, ", "))
+			}
+			risks = append(risks, policyRisk{
+				Key: "trust-oidc-sub:" + provider,
+				Finding: RuleFinding{
+					Severity: SeverityHigh,
+					Tags:     []string{"security"},
+					Title:    fmt.Sprintf("Role %s trusts any %s identity", address, provider),
+					Address:  address,
+					Why: []string{
+						subWhy,
+						"Any workload that can get a token from this provider can assume the role",
+					},
+					Recommendations: []string{
+						fmt.Sprintf("Add a StringEquals/StringLike condition on %s:sub (e.g. repo:<org>/<repo>:ref:refs/heads/main)", provider),
+					},
+				},
+			})
+		}
+		if len(conditionValues(stmt, provider+":aud")) == 0 {
+			risks = append(risks, policyRisk{
+				Key: "trust-oidc-aud:" + provider,
+				Finding: RuleFinding{
+					Severity: SeverityMedium,
+					Tags:     []string{"security"},
+					Title:    fmt.Sprintf("Role %s web identity trust does not check the %s audience", address, provider),
+					Address:  address,
+					Why: []string{
+						fmt.Sprintf("assume_role_policy Statement[%d] has no %s:aud condition", index, provider),
+						"Tokens issued for other applications of the provider are accepted",
+					},
+					Recommendations: []string{
+						fmt.Sprintf("Add a StringEquals condition on %s:aud (e.g. sts.amazonaws.com)", provider),
+					},
+				},
+			})
+		}
+	}
+	return risks
+}
+
+// anyBroadSubject reports whether a token subject pattern matches every
+// identity of the provider, or every identity of one kind: "*", "repo:*",
+// "project_path:*". Patterns pinning an owner, such as "repo:acme/*", are
+// narrow enough.
+func anyBroadSubject(subjects []string) bool {
+	for _, s := range subjects {
+		i := strings.IndexAny(s, "*?")
+		if i >= 0 && !strings.ContainsAny(strings.TrimSuffix(s[:i], ":"), ":/") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasConditionKey reports whether any condition operator tests key.
+func hasConditionKey(stmt policyStatement, key string) bool {
+	return conditionValues(stmt, key) != nil
+}
+
+// conditionValues returns the values tested for key across all condition
+// operators, or nil if key is not tested. Keys are case insensitive.
+func conditionValues(stmt policyStatement, key string) []string {
+	var values []string
+	found := false
+	for _, kv := range stmt.Condition {
+		for k, v := range kv {
+			if strings.EqualFold(k, key) {
+				found = true
+				values = append(values, toStringSlice(v)...)
+			}
+		}
+	}
+	if found && values == nil {
+		values = []string{}
+	}
+	return values
+}
+
+func containsStr(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package rules
+
+import "testing"
+
+func TestTrustPolicyPublicPrincipal(t *testing.T) {
+	checkFindings(t, evaluateAddress(t, &TrustPolicyRule{}, "iam_trust.json", "aws_iam_role.public"),
+		wantFinding{"can be assumed by any AWS principal", SeverityHigh, ""})
+}
+
+func TestTrustPolicyOIDCSubject(t *testing.T) {
+	p := loadTestPlan(t, "iam_trust.json")
+	rule := &TrustPolicyRule{}
+
+	checkFindings(t, rule.Evaluate(findChange(t, p, "aws_iam_role.github_any")),
+		wantFinding{"trusts any token.actions.githubusercontent.com identity", SeverityHigh, "without a token.actions.githubusercontent.com:sub condition"})
+	checkFindings(t, rule.Evaluate(findChange(t, p, "aws_iam_role.github_wildcard")),
+		wantFinding{"trusts any token.actions.githubusercontent.com identity", SeverityHigh, "repo:*, which matches any repository"})
+	if findings := rule.Evaluate(findChange(t, p, "aws_iam_role.github_pinned")); len(findings) != 0 {
+		t.Errorf("expected no findings for a pinned subject, got %v", findings)
+	}
+}
+
+func TestTrustPolicyThirdPartyAccount(t *testing.T) {
+	p := loadTestPlan(t, "iam_trust.json")
+	rule := &TrustPolicyRule{TrustedAccounts: []string{"222222222222"}, OwnerAccounts: PlanAccounts(p)}
+
+	checkFindings(t, rule.Evaluate(findChange(t, p, "aws_iam_role.vendor")),
+		wantFinding{"trusts third-party account 999999999999", SeverityMedium, ""},
+		wantFinding{"without sts:ExternalId", SeverityMedium, ""})
+
+	// partner trusts a configured account, self the plan's own account and
+	// lambda a service principal.
+	for _, address := range []string{"aws_iam_role.partner", "aws_iam_role.self", "aws_iam_role.lambda"} {
+		if findings := rule.Evaluate(findChange(t, p, address)); len(findings) != 0 {
+			t.Errorf("%s: expected no findings, got %v", address, findings)
+		}
+	}
+}
+
+func TestTrustPolicyTrustedAccountsWithoutOwner(t *testing.T) {
+	// A greenfield plan names no account of its own; the configured
+	// allowlist is still enforced.
+	rule := &TrustPolicyRule{TrustedAccounts: []string{"111111111111"}}
+	checkFindings(t, evaluateAddress(t, rule, "iam_trust.json", "aws_iam_role.vendor"),
+		wantFinding{"trusts third-party account 999999999999", SeverityMedium, "not listed in rules.trusted_accounts"},
+		wantFinding{"without sts:ExternalId", SeverityMedium, ""})
+}
+
+func TestTrustPolicyUnknownOwner(t *testing.T) {
+	// With no account known at all, the trusted account may be the role's
+	// own: it is still reported, at LOW.
+	checkFindings(t, evaluateAddress(t, &TrustPolicyRule{}, "iam_trust.json", "aws_iam_role.vendor"),
+		wantFinding{"trusts third-party account 999999999999", SeverityLow, "may be the resource's own account"},
+		wantFinding{"without sts:ExternalId", SeverityLow, ""})
+}
+
+func TestTrustPolicyNotCheckedByIAMRule(t *testing.T) {
+	p := loadTestPlan(t, "iam_trust.json")
+	for _, rc := range p.ResourceChanges {
+		if findings := (&IAMPolicyRule{}).Evaluate(rc); len(findings) != 0 {
+			t.Errorf("expected trust policy of %s to be left to the trust-policy rule, got %v", rc.Address, findings)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// containerDefinitionsKey is the attribute of aws_ecs_task_definition that
+// holds its containers as a JSON-encoded string.
+const containerDefinitionsKey = "container_definitions"
+
+// containerDefinitionDiffs decodes before and after container_definitions
+// strings and returns per-container, per-field differences, with paths such
+// as container_definitions[api].image. Containers are matched by name.
+// Values that may carry credentials (environment, dockerLabels, log driver
+// options, command, entryPoint, repositoryCredentials) are masked; only
+// names and keys are shown. ok is false
+// when either side is present but not a JSON array of objects, in which case
+// the caller falls back to a plain string diff.
+func containerDefinitionDiffs(before, after interface{}, bOk, aOk bool) (diffs []Diff, ok bool) {
+	var prev, next map[string]map[string]interface{}
+	var order []string
+	if bOk {
+		if prev, order, ok = decodeContainers(before, order); !ok {
+			return nil, false
+		}
+	}
+	if aOk {
+		if next, order, ok = decodeContainers(after, order); !ok {
+			return nil, false
+		}
+	}
+
+	for _, name := range order {
+		path := fmt.Sprintf("%s[%s]", containerDefinitionsKey, name)
+		b, inBefore := prev[name]
+		a, inAfter := next[name]
+		switch {
+		case !inBefore:
+			diffs = append(diffs, Diff{Path: path, Before: "(not set)", After: formatValue(a["image"])})
+		case !inAfter:
+			diffs = append(diffs, Diff{Path: path, Before: formatValue(b["image"]), After: "(removed)"})
+		default:
+			diffs = append(diffs, containerFieldDiffs(path, b, a)...)
+		}
+	}
+	return diffs, true
+}
+
+// decodeContainers parses a container_definitions value into containers
+// keyed by name (or position when unnamed), appending names not yet in order.
+func decodeContainers(v interface{}, order []string) (map[string]map[string]interface{}, []string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, order, false
+	}
+	var list []map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &list); err != nil {
+		return nil, order, false
+	}
+	seen := make(map[string]bool, len(order))
+	for _, name := range order {
+		seen[name] = true
+	}
+	containers := make(map[string]map[string]interface{}, len(list))
+	for i, c := range list {
+		name, _ := c["name"].(string)
+		if name == "" {
+			name = fmt.Sprintf("%d", i)
+		}
+		containers[name] = c
+		if !seen[name] {
+			seen[name] = true
+			order = append(order, name)
+		}
+	}
+	return containers, order, true
+}
+
+// maskedContainerFields are container fields whose values may carry
+// credentials, such as a token passed on the command line. Changes to them
+// are shown as <sensitive>.
+var maskedContainerFields = map[string]bool{
+	"command":               true,
+	"entryPoint":            true,
+	"repositoryCredentials": true,
+}
+
+// containerFieldDiffs compares the fields of one container. environment and
+// secrets are compared per variable name, dockerLabels and logConfiguration
+// options per key with their values masked.
+func containerFieldDiffs(path string, before, after map[string]interface{}) []Diff {
+	var diffs []Diff
+	for _, key := range unionKeys(before, after) {
+		fieldPath := path + "." + key
+		switch key {
+		case "environment":
+			diffs = append(diffs, namedValueDiffs(fieldPath, before[key], after[key], "value", true)...)
+		case "secrets":
+			diffs = append(diffs, namedValueDiffs(fieldPath, before[key], after[key], "valueFrom", false)...)
+		case "dockerLabels":
+			diffs = append(diffs, keyedValueDiffs(fieldPath, before[key], after[key])...)
+		case "logConfiguration":
+			b, _ := before[key].(map[string]interface{})
+			a, _ := after[key].(map[string]interface{})
+			diffs = append(diffs, logConfigurationDiffs(fieldPath, b, a)...)
+		default:
+			diffs = append(diffs, fieldDiff(fieldPath, before, after, key, maskedContainerFields[key])...)
+		}
+	}
+	return diffs
+}
+
+// logConfigurationDiffs compares a container's log configuration. Driver
+// options can hold tokens (e.g. splunk-token), so only their keys are shown.
+func logConfigurationDiffs(path string, before, after map[string]interface{}) []Diff {
+	var diffs []Diff
+	for _, key := range unionKeys(before, after) {
+		fieldPath := path + "." + key
+		switch key {
+		case "options":
+			diffs = append(diffs, keyedValueDiffs(fieldPath, before[key], after[key])...)
+		case "secretOptions":
+			diffs = append(diffs, namedValueDiffs(fieldPath, before[key], after[key], "valueFrom", false)...)
+		default:
+			diffs = append(diffs, fieldDiff(fieldPath, before, after, key, false)...)
+		}
+	}
+	return diffs
+}
+
+// keyedValueDiffs compares two string maps per key, masking their values.
+func keyedValueDiffs(path string, before, after interface{}) []Diff {
+	b, _ := before.(map[string]interface{})
+	a, _ := after.(map[string]interface{})
+	var diffs []Diff
+	for _, key := range unionKeys(b, a) {
+		diffs = append(diffs, fieldDiff(path+"."+key, b, a, key, true)...)
+	}
+	return diffs
+}
+
+// fieldDiff compares before[key] and after[key]. When mask is set, values
+// are shown as <sensitive>.
+func fieldDiff(path string, before, after map[string]interface{}, key string, mask bool) []Diff {
+	bVal, bOk := before[key]
+	aVal, aOk := after[key]
+	show := func(v interface{}) string {
+		if mask {
+			return "<sensitive>"
+		}
+		return formatValue(v)
+	}
+	switch {
+	case !bOk && !aOk:
+		return nil
+	case !bOk:
+		return []Diff{{Path: path, Before: "(not set)", After: show(aVal)}}
+	case !aOk:
+		return []Diff{{Path: path, Before: show(bVal), After: "(removed)"}}
+	}
+	bJSON, _ := json.Marshal(bVal)
+	aJSON, _ := json.Marshal(aVal)
+	if string(bJSON) == string(aJSON) {
+		return nil
+	}
+	return []Diff{{Path: path, Before: show(bVal), After: show(aVal)}}
+}
+
+// unionKeys returns the keys of both maps, sorted.
+func unionKeys(before, after map[string]interface{}) []string {
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// namedValueDiffs compares lists of {name, <valueKey>} objects, as used by
+// environment and secrets, by name. When mask is set, values are shown as
+// <sensitive>.
+func namedValueDiffs(path string, before, after interface{}, valueKey string, mask bool) []Diff {
+	prev, prevNames := namedValues(before, valueKey)
+	next, nextNames := namedValues(after, valueKey)
+	show := func(v interface{}) string {
+		if mask {
+			return "<sensitive>"
+		}
+		return formatValue(v)
+	}
+
+	names := append(prevNames, nextNames...)
+	sort.Strings(names)
+	var diffs []Diff
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+		b, bOk := prev[name]
+		a, aOk := next[name]
+		switch {
+		case !bOk:
+			diffs = append(diffs, Diff{Path: path + "." + name, Before: "(not set)", After: show(a)})
+		case !aOk:
+			diffs = append(diffs, Diff{Path: path + "." + name, Before: show(b), After: "(removed)"})
+		case formatValue(b) != formatValue(a):
+			diffs = append(diffs, Diff{Path: path + "." + name, Before: show(b), After: show(a)})
+		}
+	}
+	return diffs
+}
+
+func namedValues(v interface{}, valueKey string) (map[string]interface{}, []string) {
+	list, _ := v.([]interface{})
+	values := make(map[string]interface{}, len(list))
+	var names []string
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		if _, dup := values[name]; !dup {
+			names = append(names, name)
+		}
+		values[name] = m[valueKey]
+	}
+	return values, names
+}
@@ -20,6 +20,7 @@ func (d Diff) String() string {
 
 // ExtractDiffs computes the top-level and nested attribute differences
 // between before and after JSON blobs, respecting sensitive and unknown markers.
+// JSON-encoded container_definitions are expanded into per-container diffs.
 // Returns at most maxDiffs entries.
 func ExtractDiffs(before, after, afterSensitive, afterUnknown json.RawMessage, maxDiffs int) []Diff {
 	var beforeMap map[string]interface{}
@@ -87,6 +88,13 @@ func ExtractDiffs(before, after, afterSensitive, afterUnknown json.RawMessage, m
 			continue
 		}
 
+		if key == containerDefinitionsKey {
+			if cd, ok := containerDefinitionDiffs(bVal, aVal, bOk, aOk); ok {
+				diffs = append(diffs, cd...)
+				continue
+			}
+		}
+
 		if !bOk {
 			// New key
 			diffs = append(diffs, Diff{Path: key, Before: "(not set)", After: formatValue(aVal)})
@@ -105,6 +113,9 @@ func ExtractDiffs(before, after, afterSensitive, afterUnknown json.RawMessage, m
 		}
 	}
 
+	if maxDiffs > 0 && len(diffs) > maxDiffs {
+		diffs = diffs[:maxDiffs]
+	}
 	return diffs
 }
 
@@ -88,6 +88,139 @@ func TestExtractDiffsNullAfter(t *testing.T) {
 	}
 }
 
+func TestExtractDiffsContainerDefinitions(t *testing.T) {
+	defs := func(containers ...map[string]interface{}) string {
+		b, _ := json.Marshal(containers)
+		return string(b)
+	}
+	doc := func(defs string) json.RawMessage {
+		b, _ := json.Marshal(map[string]interface{}{"family": "api", "container_definitions": defs})
+		return b
+	}
+	before := doc(defs(
+		map[string]interface{}{
+			"name": "api", "image": "app:1.0", "essential": true,
+			"portMappings": []interface{}{map[string]interface{}{"containerPort": 8080, "protocol": "tcp"}},
+			"environment": []interface{}{
+				map[string]interface{}{"name": "LOG_LEVEL", "value": "info"},
+				map[string]interface{}{"name": "DB_PASSWORD", "value": "old"},
+				map[string]interface{}{"name": "REGION", "value": "us-east-1"},
+			},
+			"secrets": []interface{}{map[string]interface{}{"name": "API_KEY", "valueFrom": "arn:aws:ssm:::parameter/a"}},
+		},
+		map[string]interface{}{"name": "proxy", "image": "envoy:1.30"},
+	))
+	after := doc(defs(
+		map[string]interface{}{
+			"name": "api", "image": "app:1.1", "essential": false,
+			"portMappings": []interface{}{map[string]interface{}{"containerPort": 8080, "protocol": "tcp"}},
+			"environment": []interface{}{
+				map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+				map[string]interface{}{"name": "DB_PASSWORD", "value": "new"},
+				map[string]interface{}{"name": "FEATURE", "value": "on"},
+			},
+			"secrets":     []interface{}{map[string]interface{}{"name": "API_KEY", "valueFrom": "arn:aws:ssm:::parameter/b"}},
+			"healthCheck": map[string]interface{}{"command": []interface{}{"CMD", "true"}},
+		},
+		map[string]interface{}{"name": "xray", "image": "xray:3"},
+	))
+
+	want := []Diff{
+		{"container_definitions[api].environment.DB_PASSWORD", "<sensitive>", "<sensitive>"},
+		{"container_definitions[api].environment.FEATURE", "(not set)", "<sensitive>"},
+		{"container_definitions[api].environment.LOG_LEVEL", "<sensitive>", "<sensitive>"},
+		{"container_definitions[api].environment.REGION", "<sensitive>", "(removed)"},
+		{"container_definitions[api].essential", "true", "false"},
+		{"container_definitions[api].healthCheck", "(not set)", `{"command":["CMD","true"]}`},
+		{"container_definitions[api].image", `"app:1.0"`, `"app:1.1"`},
+		{"container_definitions[api].secrets.API_KEY", `"arn:aws:ssm:::parameter/a"`, `"arn:aws:ssm:::parameter/b"`},
+		{"container_definitions[proxy]", `"envoy:1.30"`, "(removed)"},
+		{"container_definitions[xray]", "(not set)", `"xray:3"`},
+	}
+	diffs := ExtractDiffs(before, after, nil, nil, 0)
+	if len(diffs) != len(want) {
+		t.Fatalf("expected %d diffs, got %d: %v", len(want), len(diffs), diffs)
+	}
+	for i, w := range want {
+		if diffs[i] != w {
+			t.Errorf("diff %d: expected %v, got %v", i, w, diffs[i])
+		}
+	}
+
+	// The limit applies to the expanded diffs.
+	if diffs := ExtractDiffs(before, after, nil, nil, 3); len(diffs) != 3 {
+		t.Errorf("expected 3 diffs (max limit), got %d", len(diffs))
+	}
+
+	// Reformatting the JSON alone is not a change.
+	compact := doc(`[{"name":"proxy","image":"envoy:1.30"}]`)
+	reformatted := doc("[\n  {\"image\": \"envoy:1.30\", \"name\": \"proxy\"}\n]")
+	if diffs := ExtractDiffs(compact, reformatted, nil, nil, 0); len(diffs) != 0 {
+		t.Errorf("expected no diffs for reformatted JSON, got %v", diffs)
+	}
+
+	// Invalid JSON falls back to a plain string diff.
+	diffs = ExtractDiffs(doc("not json"), doc("[]"), nil, nil, 0)
+	if len(diffs) != 1 || diffs[0].Path != "container_definitions" {
+		t.Errorf("expected a single container_definitions diff, got %v", diffs)
+	}
+
+	// Sensitive container_definitions stay masked as a whole.
+	diffs = ExtractDiffs(before, after, json.RawMessage(`{"container_definitions":true}`), nil, 0)
+	if len(diffs) != 1 || diffs[0].After != "<sensitive>" {
+		t.Errorf("expected a single sensitive diff, got %v", diffs)
+	}
+}
+
+func TestExtractDiffsContainerDefinitionsMasked(t *testing.T) {
+	doc := func(container map[string]interface{}) json.RawMessage {
+		defs, _ := json.Marshal([]interface{}{container})
+		b, _ := json.Marshal(map[string]interface{}{"container_definitions": string(defs)})
+		return b
+	}
+	before := doc(map[string]interface{}{
+		"name":                  "api",
+		"command":               []interface{}{"serve", "--token=old"},
+		"repositoryCredentials": map[string]interface{}{"credentialsParameter": "arn:aws:secretsmanager:::secret:a"},
+		"dockerLabels":          map[string]interface{}{"team": "core"},
+		"logConfiguration": map[string]interface{}{
+			"logDriver": "awslogs",
+			"options":   map[string]interface{}{"awslogs-group": "/ecs/api"},
+		},
+	})
+	after := doc(map[string]interface{}{
+		"name":                  "api",
+		"command":               []interface{}{"serve", "--token=new"},
+		"entryPoint":            []interface{}{"sh", "-c"},
+		"repositoryCredentials": map[string]interface{}{"credentialsParameter": "arn:aws:secretsmanager:::secret:b"},
+		"dockerLabels":          map[string]interface{}{"team": "core", "api-key": "k"},
+		"logConfiguration": map[string]interface{}{
+			"logDriver": "splunk",
+			"options":   map[string]interface{}{"splunk-token": "t0k3n", "splunk-url": "https://splunk"},
+		},
+	})
+
+	want := []Diff{
+		{"container_definitions[api].command", "<sensitive>", "<sensitive>"},
+		{"container_definitions[api].dockerLabels.api-key", "(not set)", "<sensitive>"},
+		{"container_definitions[api].entryPoint", "(not set)", "<sensitive>"},
+		{"container_definitions[api].logConfiguration.logDriver", `"awslogs"`, `"splunk"`},
+		{"container_definitions[api].logConfiguration.options.awslogs-group", "<sensitive>", "(removed)"},
+		{"container_definitions[api].logConfiguration.options.splunk-token", "(not set)", "<sensitive>"},
+		{"container_definitions[api].logConfiguration.options.splunk-url", "(not set)", "<sensitive>"},
+		{"container_definitions[api].repositoryCredentials", "<sensitive>", "<sensitive>"},
+	}
+	diffs := ExtractDiffs(before, after, nil, nil, 0)
+	if len(diffs) != len(want) {
+		t.Fatalf("expected %d diffs, got %d: %v", len(want), len(diffs), diffs)
+	}
+	for i, w := range want {
+		if diffs[i] != w {
+			t.Errorf("diff %d: expected %v, got %v", i, w, diffs[i])
+		}
+	}
+}
+
 func TestExtractReplacePaths(t *testing.T) {
 	raw := json.RawMessage(`[["ami"],["tags","Name"]]`)
 	paths := ExtractReplacePaths(raw)
@@ -0,0 +1,35 @@
+package util
+
+// MatchGlob reports whether s matches pattern, where "*" matches any run of
+// characters (including none) and "?" matches exactly one character.
+// Unlike path.Match, brackets are literal so that Terraform addresses such as
+// `aws_instance.web["a"]` can be matched without escaping.
+func MatchGlob(pattern, s string) bool {
+	p := []rune(pattern)
+	str := []rune(s)
+
+	pi, si := 0, 0
+	starP, starS := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			starP = pi
+			starS = si
+			pi++
+		case starP >= 0:
+			// Backtrack: let the last "*" absorb one more character.
+			pi = starP + 1
+			starS++
+			si = starS
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
@@ -0,0 +1,28 @@
+package util
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    bool
+	}{
+		{"aws_ecs_*", "aws_ecs_service", true},
+		{"aws_ecs_*", "aws_db_instance", false},
+		{"*", "", true},
+		{"module.prod.*", "module.prod.aws_ecs_service.api", true},
+		{"module.prod.*", "module.dev.aws_ecs_service.api", false},
+		{`aws_instance.web["a"]`, `aws_instance.web["a"]`, true},
+		{"aws_instance.web[?]", "aws_instance.web[0]", true},
+		{"*.api", "aws_ecs_service.api", true},
+		{"*.api", "aws_ecs_service.api2", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.input); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.input, got, tt.want)
+		}
+	}
+}